@@ -0,0 +1,262 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+)
+
+// GrantResourceCreatorActions will grant the creator the actions configured in resource_creator_actions
+// of the created resource, return the granted policies
+func (i *IAM) GrantResourceCreatorActions(request ResourceCreatorActionRequest) ([]AuthorizationPolicy, error) {
+	return i.GrantResourceCreatorActionsCtx(context.Background(), request)
+}
+
+// GrantResourceCreatorActionsCtx will grant the creator the actions of the created resource with the ctx
+func (i *IAM) GrantResourceCreatorActionsCtx(
+	ctx context.Context,
+	request ResourceCreatorActionRequest,
+) ([]AuthorizationPolicy, error) {
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := i.client.GrantResourceCreatorActionsCtx(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("grant resource creator actions fail: %w", err)
+	}
+
+	return newAuthorizationPolicies(policies), nil
+}
+
+// GrantBatchResourceCreatorActions will grant the creator the actions configured in resource_creator_actions
+// of the created resources, return the granted policies
+func (i *IAM) GrantBatchResourceCreatorActions(request BatchResourceCreatorActionRequest) ([]AuthorizationPolicy, error) {
+	return i.GrantBatchResourceCreatorActionsCtx(context.Background(), request)
+}
+
+// GrantBatchResourceCreatorActionsCtx will grant the creator the actions of the created resources with the ctx
+func (i *IAM) GrantBatchResourceCreatorActionsCtx(
+	ctx context.Context,
+	request BatchResourceCreatorActionRequest,
+) ([]AuthorizationPolicy, error) {
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := i.client.GrantBatchResourceCreatorActionsCtx(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("grant batch resource creator actions fail: %w", err)
+	}
+
+	return newAuthorizationPolicies(policies), nil
+}
+
+// GrantInstancePermission will grant the subject the permission of the action on the resource instances,
+// return the created policy
+func (i *IAM) GrantInstancePermission(request InstancePermissionRequest) (AuthorizationPolicy, error) {
+	return i.GrantInstancePermissionCtx(context.Background(), request)
+}
+
+// GrantInstancePermissionCtx will grant the permission of the resource instances with the ctx
+func (i *IAM) GrantInstancePermissionCtx(
+	ctx context.Context,
+	request InstancePermissionRequest,
+) (AuthorizationPolicy, error) {
+	request.Operate = operateGrant
+	return i.grantOrRevokeInstancePermission(ctx, request)
+}
+
+// RevokeInstancePermission will revoke the permission of the action on the resource instances from the subject,
+// return the updated policy
+func (i *IAM) RevokeInstancePermission(request InstancePermissionRequest) (AuthorizationPolicy, error) {
+	return i.RevokeInstancePermissionCtx(context.Background(), request)
+}
+
+// RevokeInstancePermissionCtx will revoke the permission of the resource instances with the ctx
+func (i *IAM) RevokeInstancePermissionCtx(
+	ctx context.Context,
+	request InstancePermissionRequest,
+) (AuthorizationPolicy, error) {
+	request.Operate = operateRevoke
+	return i.grantOrRevokeInstancePermission(ctx, request)
+}
+
+func (i *IAM) grantOrRevokeInstancePermission(
+	ctx context.Context,
+	request InstancePermissionRequest,
+) (policy AuthorizationPolicy, err error) {
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+
+	p, err := i.client.GrantOrRevokeInstancePermissionCtx(ctx, request)
+	if err != nil {
+		err = fmt.Errorf("%s instance permission fail: %w", request.Operate, err)
+		return
+	}
+
+	// the response has no action
+	return AuthorizationPolicy{Action: request.Action, PolicyID: p.PolicyID}, nil
+}
+
+// BatchGrantInstancePermission will grant the subject the permissions of the actions on the resource instances,
+// return the created policies
+func (i *IAM) BatchGrantInstancePermission(request BatchInstancePermissionRequest) ([]AuthorizationPolicy, error) {
+	return i.BatchGrantInstancePermissionCtx(context.Background(), request)
+}
+
+// BatchGrantInstancePermissionCtx will grant the permissions of the resource instances with the ctx
+func (i *IAM) BatchGrantInstancePermissionCtx(
+	ctx context.Context,
+	request BatchInstancePermissionRequest,
+) ([]AuthorizationPolicy, error) {
+	request.Operate = operateGrant
+	return i.batchGrantOrRevokeInstancePermission(ctx, request)
+}
+
+// BatchRevokeInstancePermission will revoke the permissions of the actions on the resource instances
+// from the subject, return the updated policies
+func (i *IAM) BatchRevokeInstancePermission(request BatchInstancePermissionRequest) ([]AuthorizationPolicy, error) {
+	return i.BatchRevokeInstancePermissionCtx(context.Background(), request)
+}
+
+// BatchRevokeInstancePermissionCtx will revoke the permissions of the resource instances with the ctx
+func (i *IAM) BatchRevokeInstancePermissionCtx(
+	ctx context.Context,
+	request BatchInstancePermissionRequest,
+) ([]AuthorizationPolicy, error) {
+	request.Operate = operateRevoke
+	return i.batchGrantOrRevokeInstancePermission(ctx, request)
+}
+
+func (i *IAM) batchGrantOrRevokeInstancePermission(
+	ctx context.Context,
+	request BatchInstancePermissionRequest,
+) ([]AuthorizationPolicy, error) {
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := i.client.BatchGrantOrRevokeInstancePermissionCtx(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("batch %s instance permission fail: %w", request.Operate, err)
+	}
+
+	return newAuthorizationPolicies(policies), nil
+}
+
+// GrantPathPermission will grant the subject the permission of the action on all the instances under
+// the topology paths, return the created policy
+func (i *IAM) GrantPathPermission(request PathPermissionRequest) (AuthorizationPolicy, error) {
+	return i.GrantPathPermissionCtx(context.Background(), request)
+}
+
+// GrantPathPermissionCtx will grant the permission of the topology paths with the ctx
+func (i *IAM) GrantPathPermissionCtx(ctx context.Context, request PathPermissionRequest) (AuthorizationPolicy, error) {
+	request.Operate = operateGrant
+	return i.grantOrRevokePathPermission(ctx, request)
+}
+
+// RevokePathPermission will revoke the permission of the action on the topology paths from the subject,
+// return the updated policy
+func (i *IAM) RevokePathPermission(request PathPermissionRequest) (AuthorizationPolicy, error) {
+	return i.RevokePathPermissionCtx(context.Background(), request)
+}
+
+// RevokePathPermissionCtx will revoke the permission of the topology paths with the ctx
+func (i *IAM) RevokePathPermissionCtx(ctx context.Context, request PathPermissionRequest) (AuthorizationPolicy, error) {
+	request.Operate = operateRevoke
+	return i.grantOrRevokePathPermission(ctx, request)
+}
+
+func (i *IAM) grantOrRevokePathPermission(
+	ctx context.Context,
+	request PathPermissionRequest,
+) (policy AuthorizationPolicy, err error) {
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+
+	p, err := i.client.GrantOrRevokePathPermissionCtx(ctx, request)
+	if err != nil {
+		err = fmt.Errorf("%s path permission fail: %w", request.Operate, err)
+		return
+	}
+
+	// the response has no action
+	return AuthorizationPolicy{Action: request.Action, PolicyID: p.PolicyID}, nil
+}
+
+// BatchGrantPathPermission will grant the subject the permissions of the actions on all the instances under
+// the topology paths, return the created policies
+func (i *IAM) BatchGrantPathPermission(request BatchPathPermissionRequest) ([]AuthorizationPolicy, error) {
+	return i.BatchGrantPathPermissionCtx(context.Background(), request)
+}
+
+// BatchGrantPathPermissionCtx will grant the permissions of the topology paths with the ctx
+func (i *IAM) BatchGrantPathPermissionCtx(
+	ctx context.Context,
+	request BatchPathPermissionRequest,
+) ([]AuthorizationPolicy, error) {
+	request.Operate = operateGrant
+	return i.batchGrantOrRevokePathPermission(ctx, request)
+}
+
+// BatchRevokePathPermission will revoke the permissions of the actions on the topology paths from the subject,
+// return the updated policies
+func (i *IAM) BatchRevokePathPermission(request BatchPathPermissionRequest) ([]AuthorizationPolicy, error) {
+	return i.BatchRevokePathPermissionCtx(context.Background(), request)
+}
+
+// BatchRevokePathPermissionCtx will revoke the permissions of the topology paths with the ctx
+func (i *IAM) BatchRevokePathPermissionCtx(
+	ctx context.Context,
+	request BatchPathPermissionRequest,
+) ([]AuthorizationPolicy, error) {
+	request.Operate = operateRevoke
+	return i.batchGrantOrRevokePathPermission(ctx, request)
+}
+
+func (i *IAM) batchGrantOrRevokePathPermission(
+	ctx context.Context,
+	request BatchPathPermissionRequest,
+) ([]AuthorizationPolicy, error) {
+	err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := i.client.BatchGrantOrRevokePathPermissionCtx(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("batch %s path permission fail: %w", request.Operate, err)
+	}
+
+	return newAuthorizationPolicies(policies), nil
+}
+
+// newAuthorizationPolicies converts the policies of the iam backend response
+func newAuthorizationPolicies(policies []client.AuthorizationPolicy) []AuthorizationPolicy {
+	result := make([]AuthorizationPolicy, 0, len(policies))
+	for _, p := range policies {
+		result = append(result, AuthorizationPolicy{Action: NewAction(p.Action.ID), PolicyID: p.PolicyID})
+	}
+	return result
+}
@@ -0,0 +1,360 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("authorization", func() {
+	var ts *httptest.Server
+	var paths []string
+	var bodies []map[string]interface{}
+	var i *IAM
+
+	BeforeEach(func() {
+		paths = nil
+		bodies = nil
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			var body map[string]interface{}
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &body)
+			bodies = append(bodies, body)
+
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/api/v1/open/authorization/instance/" || r.URL.Path == "/api/v1/open/authorization/path/" {
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok",
+					"data": {"policy_id": 3, "statistics": {"instance_count": 1}}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": [
+				{"action": {"id": "task_edit"}, "policy_id": 1},
+				{"action": {"id": "task_view"}, "policy_id": 2}
+			]}`))
+		}))
+		i = NewAPIGatewayIAM("bk_sops", "bk_sops", "{app_secret}", ts.URL)
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	expected := []AuthorizationPolicy{
+		{Action: NewAction("task_edit"), PolicyID: 1},
+		{Action: NewAction("task_view"), PolicyID: 2},
+	}
+
+	Context("GrantResourceCreatorActions", func() {
+		It("ok", func() {
+			request := NewResourceCreatorActionRequest("bk_sops", "task", "1", "task1", "admin",
+				[]ResourceCreatorAncestor{NewResourceCreatorAncestor("bk_sops", "project", "1")})
+
+			policies, err := i.GrantResourceCreatorActions(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, policies)
+			assert.Equal(GinkgoT(), []string{"/api/v1/open/authorization/resource_creator_action/"}, paths)
+			assert.Equal(GinkgoT(), "admin", bodies[0]["creator"])
+			assert.Equal(GinkgoT(), []interface{}{
+				map[string]interface{}{"system": "bk_sops", "type": "project", "id": "1"},
+			}, bodies[0]["ancestors"])
+		})
+
+		It("invalid ancestors", func() {
+			request := NewResourceCreatorActionRequest("bk_sops", "task", "1", "task1", "admin",
+				[]ResourceCreatorAncestor{NewResourceCreatorAncestor("bk_sops", "", "1")})
+
+			_, err := i.GrantResourceCreatorActions(request)
+
+			assert.ErrorContains(GinkgoT(), err, "ancestors[0] invalid")
+			assert.Empty(GinkgoT(), paths)
+		})
+
+		It("invalid request", func() {
+			_, err := i.GrantResourceCreatorActions(ResourceCreatorActionRequest{System: "bk_sops"})
+
+			assert.Error(GinkgoT(), err)
+			assert.Empty(GinkgoT(), paths)
+		})
+	})
+
+	Context("GrantBatchResourceCreatorActions", func() {
+		It("ok", func() {
+			request := NewBatchResourceCreatorActionRequest("bk_sops", "task", "admin", []ResourceCreatorInstance{
+				NewResourceCreatorInstance("1", "task1", nil),
+				NewResourceCreatorInstance("2", "task2", []ResourceCreatorAncestor{
+					NewResourceCreatorAncestor("bk_sops", "project", "1"),
+				}),
+			})
+
+			policies, err := i.GrantBatchResourceCreatorActions(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, policies)
+			assert.Equal(GinkgoT(), []string{"/api/v1/open/authorization/batch_resource_creator_action/"}, paths)
+			assert.Len(GinkgoT(), bodies[0]["instances"], 2)
+		})
+
+		It("no instances", func() {
+			request := NewBatchResourceCreatorActionRequest("bk_sops", "task", "admin", nil)
+
+			_, err := i.GrantBatchResourceCreatorActions(request)
+
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("invalid ancestors", func() {
+			request := NewBatchResourceCreatorActionRequest("bk_sops", "task", "admin", []ResourceCreatorInstance{
+				NewResourceCreatorInstance("1", "task1", []ResourceCreatorAncestor{{Type: "project", ID: "1"}}),
+			})
+
+			_, err := i.GrantBatchResourceCreatorActions(request)
+
+			assert.ErrorContains(GinkgoT(), err, "instances[0] invalid: the ancestors[0] invalid")
+		})
+	})
+
+	Context("InstancePermission", func() {
+		request := NewInstancePermissionRequest("bk_sops", NewSubject("user", "admin"), NewAction("task_view"),
+			[]InstancePermissionResource{NewInstancePermissionResource("bk_sops", "task", "1", "task1")})
+
+		It("grant", func() {
+			policy, err := i.GrantInstancePermission(request.WithExpiry(time.Unix(4102444800, 0)))
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), AuthorizationPolicy{Action: NewAction("task_view"), PolicyID: 3}, policy)
+			assert.Equal(GinkgoT(), []string{"/api/v1/open/authorization/instance/"}, paths)
+			assert.Equal(GinkgoT(), "grant", bodies[0]["operate"])
+			assert.Equal(GinkgoT(), float64(4102444800), bodies[0]["expired_at"])
+			assert.Equal(GinkgoT(), []interface{}{
+				map[string]interface{}{"system": "bk_sops", "type": "task", "id": "1", "name": "task1"},
+			}, bodies[0]["resources"])
+		})
+
+		It("revoke", func() {
+			_, err := i.RevokeInstancePermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "revoke", bodies[0]["operate"])
+			assert.NotContains(GinkgoT(), bodies[0], "expired_at")
+		})
+
+		It("invalid", func() {
+			invalid := NewInstancePermissionRequest("bk_sops", NewSubject("user", "admin"), NewAction("task_view"),
+				[]InstancePermissionResource{NewInstancePermissionResource("bk_sops", "task", "1", "")})
+
+			_, err := i.GrantInstancePermission(invalid)
+
+			assert.ErrorContains(GinkgoT(), err, "resources[0] invalid")
+			assert.Empty(GinkgoT(), paths)
+		})
+	})
+
+	Context("BatchInstancePermission", func() {
+		request := NewBatchInstancePermissionRequest("bk_sops", NewSubject("user", "admin"),
+			[]Action{NewAction("task_edit"), NewAction("task_view")},
+			[]BatchInstancePermissionResource{
+				NewBatchInstancePermissionResource("bk_sops", "task", [][]PermissionResourceNode{
+					{NewPermissionResourceNode("project", "1", "project1"), NewPermissionResourceNode("task", "1", "task1")},
+				}),
+			})
+
+		It("grant", func() {
+			policies, err := i.BatchGrantInstancePermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, policies)
+			assert.Equal(GinkgoT(), []string{"/api/v1/open/authorization/batch_instance/"}, paths)
+			assert.Equal(GinkgoT(), "grant", bodies[0]["operate"])
+		})
+
+		It("revoke", func() {
+			policies, err := i.BatchRevokeInstancePermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, policies)
+			assert.Equal(GinkgoT(), "revoke", bodies[0]["operate"])
+		})
+
+		It("invalid", func() {
+			invalid := NewBatchInstancePermissionRequest("bk_sops", NewSubject("user", "admin"),
+				[]Action{NewAction("task_edit")},
+				[]BatchInstancePermissionResource{
+					NewBatchInstancePermissionResource("bk_sops", "task", [][]PermissionResourceNode{{}}),
+				})
+
+			_, err := i.BatchGrantInstancePermission(invalid)
+
+			assert.ErrorContains(GinkgoT(), err, "resources[0].instances[0] invalid")
+		})
+	})
+
+	Context("PermissionPath", func() {
+		It("from ancestors", func() {
+			path := NewPermissionPath(
+				NewResourceNode("bk_cmdb", "biz", "1", map[string]interface{}{"name": "biz1"}),
+				NewResourceNode("bk_cmdb", "set", "2", nil),
+			)
+
+			assert.Equal(GinkgoT(), PermissionPath{
+				NewPermissionResourceNode("biz", "1", "biz1"),
+				NewPermissionResourceNode("set", "2", "2"),
+			}, path)
+			assert.Equal(GinkgoT(), "/biz,1/set,2/", path.String())
+		})
+
+		It("match the _bk_iam_path_ of the evaluator", func() {
+			host := NewResourceNode("bk_cmdb", "host", "1", map[string]interface{}{
+				expression.KeywordBKIAMPath: "/biz,1/set,2/module,3/",
+			})
+			objSet := expression.NewObjectSet()
+			objSet.Set("host", host.Attribute)
+
+			cases := []struct {
+				path    PermissionPath
+				allowed bool
+			}{
+				{PermissionPath{NewPermissionResourceNode("biz", "1", "biz1")}, true},
+				{PermissionPath{
+					NewPermissionResourceNode("biz", "1", "biz1"),
+					NewPermissionResourceNode("set", "2", "set2"),
+					NewPermissionResourceNode("module", "3", "module3"),
+				}, true},
+				{PermissionPath{
+					NewPermissionResourceNode("biz", "1", "biz1"),
+					NewPermissionResourceNode("set", PermissionPathAnyID, ""),
+				}, true},
+				{PermissionPath{NewPermissionResourceNode("biz", "2", "biz2")}, false},
+				{PermissionPath{
+					NewPermissionResourceNode("biz", "1", "biz1"),
+					NewPermissionResourceNode("module", PermissionPathAnyID, ""),
+				}, false},
+			}
+			for _, c := range cases {
+				expr := expression.ExprCell{
+					OP:    operator.StartsWith,
+					Field: "host" + expression.KeywordBKIAMPathFieldSuffix,
+					Value: c.path.String(),
+				}
+				assert.Equal(GinkgoT(), c.allowed, expr.Eval(objSet), c.path.String())
+			}
+		})
+
+		It("invalid", func() {
+			assert.ErrorContains(GinkgoT(), PermissionPath{}.Validate(), "at least 1")
+			assert.ErrorContains(GinkgoT(), PermissionPath{
+				NewPermissionResourceNode("biz", PermissionPathAnyID, ""),
+				NewPermissionResourceNode("set", "2", "set2"),
+			}.Validate(), "only the last node")
+			assert.NoError(GinkgoT(), PermissionPath{
+				NewPermissionResourceNode("biz", "1", "biz1"),
+				NewPermissionResourceNode("set", PermissionPathAnyID, ""),
+			}.Validate())
+		})
+	})
+
+	Context("PathPermission", func() {
+		request := NewPathPermissionRequest("bk_cmdb", NewSubject("user", "admin"), NewAction("host_edit"),
+			[]PathPermissionResource{
+				NewPathPermissionResource("bk_cmdb", "host", PermissionPath{
+					NewPermissionResourceNode("biz", "1", "biz1"),
+					NewPermissionResourceNode("module", "3", "module3"),
+				}),
+			})
+
+		It("grant", func() {
+			policy, err := i.GrantPathPermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), AuthorizationPolicy{Action: NewAction("host_edit"), PolicyID: 3}, policy)
+			assert.Equal(GinkgoT(), []string{"/api/v1/open/authorization/path/"}, paths)
+			assert.Equal(GinkgoT(), "grant", bodies[0]["operate"])
+			assert.Equal(GinkgoT(), []interface{}{
+				map[string]interface{}{
+					"system": "bk_cmdb",
+					"type":   "host",
+					"path": []interface{}{
+						map[string]interface{}{"type": "biz", "id": "1", "name": "biz1"},
+						map[string]interface{}{"type": "module", "id": "3", "name": "module3"},
+					},
+				},
+			}, bodies[0]["resources"])
+		})
+
+		It("revoke", func() {
+			policy, err := i.RevokePathPermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(3), policy.PolicyID)
+			assert.Equal(GinkgoT(), "revoke", bodies[0]["operate"])
+		})
+
+		It("invalid", func() {
+			invalid := NewPathPermissionRequest("bk_cmdb", NewSubject("user", "admin"), NewAction("host_edit"),
+				[]PathPermissionResource{NewPathPermissionResource("bk_cmdb", "host", PermissionPath{})})
+
+			_, err := i.GrantPathPermission(invalid)
+
+			assert.ErrorContains(GinkgoT(), err, "resources[0].path invalid")
+			assert.Empty(GinkgoT(), paths)
+		})
+	})
+
+	Context("BatchPathPermission", func() {
+		request := NewBatchPathPermissionRequest("bk_sops", NewSubject("user", "admin"),
+			[]Action{NewAction("task_edit"), NewAction("task_view")},
+			[]BatchPathPermissionResource{
+				NewBatchPathPermissionResource("bk_sops", "task", []PermissionPath{
+					{NewPermissionResourceNode("project", "1", "project1")},
+					{NewPermissionResourceNode("project", "2", "project2")},
+				}),
+			})
+
+		It("grant", func() {
+			policies, err := i.BatchGrantPathPermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, policies)
+			assert.Equal(GinkgoT(), []string{"/api/v1/open/authorization/batch_path/"}, paths)
+			assert.Equal(GinkgoT(), "grant", bodies[0]["operate"])
+		})
+
+		It("revoke", func() {
+			policies, err := i.BatchRevokePathPermission(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, policies)
+			assert.Equal(GinkgoT(), "revoke", bodies[0]["operate"])
+		})
+
+		It("invalid", func() {
+			invalid := NewBatchPathPermissionRequest("bk_sops", NewSubject("user", "admin"),
+				[]Action{NewAction("task_edit")},
+				[]BatchPathPermissionResource{NewBatchPathPermissionResource("bk_sops", "task", nil)})
+
+			_, err := i.BatchGrantPathPermission(invalid)
+
+			assert.ErrorContains(GinkgoT(), err, "resources[0].paths invalid")
+		})
+	})
+})
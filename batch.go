@@ -0,0 +1,230 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/logger"
+)
+
+// batchChunkSize is the number of resources evaluated by a worker at a time,
+// the resources list shorter than it is evaluated in the caller goroutine
+const batchChunkSize = 64
+
+// BatchIsAllowedList will batch check the permission for resources lists, the results are in the same order
+func (i *IAM) BatchIsAllowedList(request Request, resourcesList []Resources) (result []ResourceAllowed, err error) {
+	return i.BatchIsAllowedListCtx(context.Background(), request, resourcesList)
+}
+
+// BatchIsAllowedListCtx will batch check the permission for resources lists with the ctx,
+// the results are in the same order; the policies are queried and compiled once,
+// then the resources are evaluated concurrently, see WithBatchConcurrency
+func (i *IAM) BatchIsAllowedListCtx(
+	ctx context.Context,
+	request Request,
+	resourcesList []Resources,
+) (result []ResourceAllowed, err error) {
+	// 1. validate
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+
+	// 2. policy query without resources
+	if len(request.Resources) != 0 {
+		request.Resources = Resources{}
+	}
+
+	if i.serverSideAuth {
+		return i.batchIsAllowedByServer(ctx, request, resourcesList)
+	}
+
+	data, err := i.client.V2PolicyQueryTypedCtx(ctx, request.System, request)
+	if err != nil {
+		return
+	}
+	eval := compileCondition(data.ExprCell)
+
+	result = make([]ResourceAllowed, len(resourcesList))
+	err = i.evalConcurrently(ctx, len(resourcesList), func(idx int) {
+		resources := resourcesList[idx]
+
+		// 3. make objSet and eval
+		result[idx] = ResourceAllowed{
+			ResourceID: i.buildResourceID(resources),
+			Allowed:    eval(NewObjectSet(resources)),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BatchResourceMultiActionsAllowedList will check the permissions of batch-resource with multi-actions,
+// the results are in the same order
+func (i *IAM) BatchResourceMultiActionsAllowedList(
+	request MultiActionRequest,
+	resourcesList []Resources,
+) (results []ResourceActionsAllowed, err error) {
+	return i.BatchResourceMultiActionsAllowedListCtx(context.Background(), request, resourcesList)
+}
+
+// BatchResourceMultiActionsAllowedListCtx will check the permissions of batch-resource with multi-actions with the ctx,
+// the results are in the same order; the policies are queried and compiled once,
+// then the resources are evaluated concurrently, see WithBatchConcurrency
+func (i *IAM) BatchResourceMultiActionsAllowedListCtx(
+	ctx context.Context,
+	request MultiActionRequest,
+	resourcesList []Resources,
+) (results []ResourceActionsAllowed, err error) {
+	// 1. validate
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+
+	// 2. policy query without resources
+	if len(request.Resources) != 0 {
+		request.Resources = Resources{}
+	}
+
+	if i.serverSideAuth {
+		return i.batchResourceMultiActionsAllowedByServer(ctx, request, resourcesList)
+	}
+
+	// 3. batch action policy query
+	logger.Debugf("the request: %v", request)
+	actionPolicies, err := i.client.V2PolicyQueryByActionsTypedCtx(ctx, request.System, request)
+	if err != nil {
+		logger.Errorf("do policy query by actions fail! err=%w", err)
+		return
+	}
+	logger.Debugf("the return policies of actions: %#v", actionPolicies)
+
+	evals := make([]func(expression.ObjectSetInterface) bool, 0, len(actionPolicies))
+	for _, actionPolicy := range actionPolicies {
+		evals = append(evals, compileCondition(actionPolicy.Condition))
+	}
+
+	results = make([]ResourceActionsAllowed, len(resourcesList))
+	err = i.evalConcurrently(ctx, len(resourcesList), func(idx int) {
+		resources := resourcesList[idx]
+
+		// 4. make objSet
+		objSet := NewObjectSet(resources)
+
+		// 5. calculate perms
+		result := make(map[string]bool, len(actionPolicies))
+		for n, actionPolicy := range actionPolicies {
+			result[actionPolicy.Action.ID] = evals[n](objSet)
+		}
+		results[idx] = ResourceActionsAllowed{ResourceID: i.buildResourceID(resources), Actions: result}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// compileCondition will compile the condition for evaluating many resources,
+// fallback to ExprCell.Eval if failed, e.g. an unsupported operator, which is evaluated as false
+func compileCondition(expr expression.ExprCell) func(expression.ObjectSetInterface) bool {
+	compiled, err := expression.Compile(expr)
+	if err != nil {
+		logger.Debugf("compile the condition fail, fallback to eval! err=%s", err)
+		return expr.Eval
+	}
+	return compiled.Eval
+}
+
+// evalConcurrently will call eval for each index in [0, n) across at most batchConcurrency workers,
+// it stops on ctx done and returns ctx.Err()
+func (i *IAM) evalConcurrently(ctx context.Context, n int, eval func(idx int)) error {
+	return i.runConcurrently(ctx, n, batchChunkSize, func(_ context.Context, idx int) error {
+		eval(idx)
+		return nil
+	})
+}
+
+// runConcurrently will call fn for each index in [0, n) across at most batchConcurrency workers,
+// each worker takes chunkSize indexes at a time; it stops on the first error or ctx done, and returns the error;
+// the ctx passed to fn is canceled on the first error, so the inflight calls of the other workers can stop early
+func (i *IAM) runConcurrently(
+	ctx context.Context,
+	n, chunkSize int,
+	fn func(ctx context.Context, idx int) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		next     int64
+		firstErr error
+		once     sync.Once
+	)
+	run := func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			end := int(atomic.AddInt64(&next, int64(chunkSize)))
+			start := end - chunkSize
+			if start >= n {
+				return
+			}
+			if end > n {
+				end = n
+			}
+			for idx := start; idx < end; idx++ {
+				if err := fn(ctx, idx); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}
+	}
+
+	workers := (n + chunkSize - 1) / chunkSize
+	if workers > i.batchConcurrency {
+		workers = i.batchConcurrency
+	}
+
+	if workers <= 1 {
+		run()
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				run()
+			}()
+		}
+		wg.Wait()
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
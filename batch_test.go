@@ -0,0 +1,235 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("batch", func() {
+	subject := NewSubject("user", "admin")
+
+	var cli *client.MemoryClient
+	var resourcesList []Resources
+
+	BeforeEach(func() {
+		cli = client.NewMemoryClient()
+		cli.Grant("bk_paas", "user", "admin", "develop_app",
+			expression.ExprCell{OP: operator.In, Field: "app.id", Value: []interface{}{"1", "10", "100", "999"}})
+		cli.GrantAny("bk_paas", "user", "admin", "view_app")
+		// the unsupported operator is evaluated as false
+		cli.Grant("bk_paas", "user", "admin", "delete_app",
+			expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{
+				{OP: "regex", Field: "app.id", Value: ".*"},
+				{OP: operator.Eq, Field: "app.id", Value: "2"},
+			}})
+
+		resourcesList = make([]Resources, 0, 1000)
+		for n := 0; n < 1000; n++ {
+			resourcesList = append(resourcesList,
+				Resources{NewResourceNode("bk_paas", "app", fmt.Sprintf("%d", n), map[string]interface{}{})})
+		}
+	})
+
+	It("BatchIsAllowedList", func() {
+		request := NewRequest("bk_paas", subject, NewAction("develop_app"), nil)
+
+		for _, concurrency := range []int{0, 1, 4, 64} {
+			i := NewWithClient(cli, WithBatchConcurrency(concurrency))
+
+			list, err := i.BatchIsAllowedList(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), list, len(resourcesList))
+			for n, r := range list {
+				assert.Equal(GinkgoT(), fmt.Sprintf("%d", n), r.ResourceID)
+				assert.Equal(GinkgoT(), n == 1 || n == 10 || n == 100 || n == 999, r.Allowed, r.ResourceID)
+			}
+
+			result, err := i.BatchIsAllowed(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), result, len(resourcesList))
+			for _, r := range list {
+				assert.Equal(GinkgoT(), r.Allowed, result[r.ResourceID])
+			}
+		}
+	})
+
+	It("BatchResourceMultiActionsAllowedList", func() {
+		request := NewMultiActionRequest("bk_paas", subject,
+			[]Action{NewAction("develop_app"), NewAction("view_app"), NewAction("delete_app")}, nil)
+
+		for _, concurrency := range []int{1, 4} {
+			i := NewWithClient(cli, WithBatchConcurrency(concurrency))
+
+			list, err := i.BatchResourceMultiActionsAllowedList(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), list, len(resourcesList))
+			for n, r := range list {
+				assert.Equal(GinkgoT(), fmt.Sprintf("%d", n), r.ResourceID)
+				assert.Equal(GinkgoT(), map[string]bool{
+					"develop_app": n == 1 || n == 10 || n == 100 || n == 999,
+					"view_app":    true,
+					"delete_app":  n == 2,
+				}, r.Actions, r.ResourceID)
+			}
+
+			results, err := i.BatchResourceMultiActionsAllowed(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), results, len(resourcesList))
+			for _, r := range list {
+				assert.Equal(GinkgoT(), r.Actions, results[r.ResourceID])
+			}
+		}
+	})
+
+	It("server side auth", func() {
+		local := NewWithClient(cli)
+		server := NewWithClient(cli, WithServerSideAuth(true))
+
+		request := NewRequest("bk_paas", subject, NewAction("develop_app"), nil)
+		expected, err := local.BatchIsAllowedList(request, resourcesList[:20])
+		assert.NoError(GinkgoT(), err)
+		list, err := server.BatchIsAllowedList(request, resourcesList[:20])
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), expected, list)
+
+		multiRequest := NewMultiActionRequest("bk_paas", subject,
+			[]Action{NewAction("develop_app"), NewAction("view_app")}, nil)
+		expectedResults, err := local.BatchResourceMultiActionsAllowedList(multiRequest, resourcesList[:20])
+		assert.NoError(GinkgoT(), err)
+		results, err := server.BatchResourceMultiActionsAllowedList(multiRequest, resourcesList[:20])
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), expectedResults, results)
+	})
+
+	It("server side auth concurrently", func() {
+		var inflight, maxInflight int64
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt64(&inflight, 1)
+			defer atomic.AddInt64(&inflight, -1)
+			for {
+				m := atomic.LoadInt64(&maxInflight)
+				if n <= m || atomic.CompareAndSwapInt64(&maxInflight, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"develop_app": true}}`))
+		}))
+		defer ts.Close()
+
+		i := NewDirectIAM("bk_paas", "bk_paas", "{app_secret}", ts.URL,
+			WithServerSideAuth(true), WithBatchConcurrency(4))
+		request := NewMultiActionRequest("bk_paas", subject, []Action{NewAction("develop_app")}, nil)
+
+		results, err := i.BatchResourceMultiActionsAllowedList(request, resourcesList[:16])
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), results, 16)
+		for n, r := range results {
+			assert.Equal(GinkgoT(), fmt.Sprintf("%d", n), r.ResourceID)
+			assert.Equal(GinkgoT(), map[string]bool{"develop_app": true}, r.Actions)
+		}
+		assert.Greater(GinkgoT(), atomic.LoadInt64(&maxInflight), int64(1))
+		assert.LessOrEqual(GinkgoT(), atomic.LoadInt64(&maxInflight), int64(4))
+	})
+
+	It("stop on the first error", func() {
+		i := NewWithClient(cli, WithBatchConcurrency(4))
+
+		errFail := errors.New("fail")
+		var calls int64
+		err := i.runConcurrently(context.Background(), len(resourcesList), 1, func(_ context.Context, idx int) error {
+			atomic.AddInt64(&calls, 1)
+			if idx == 0 {
+				return errFail
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+		assert.ErrorIs(GinkgoT(), err, errFail)
+		assert.Less(GinkgoT(), atomic.LoadInt64(&calls), int64(len(resourcesList)))
+	})
+
+	It("cancel the inflight calls on the first error", func() {
+		i := NewWithClient(cli, WithBatchConcurrency(2))
+
+		errFail := errors.New("fail")
+		started := make(chan struct{}, 1)
+		canceled := make(chan struct{}, 1)
+		err := i.runConcurrently(context.Background(), 2, 1, func(ctx context.Context, idx int) error {
+			if idx == 0 {
+				// fail after the other call is inflight
+				select {
+				case <-started:
+				case <-time.After(time.Second):
+				}
+				return errFail
+			}
+
+			started <- struct{}{}
+			select {
+			case <-ctx.Done():
+				canceled <- struct{}{}
+			case <-time.After(time.Second):
+			}
+			return nil
+		})
+		assert.ErrorIs(GinkgoT(), err, errFail)
+		assert.Len(GinkgoT(), canceled, 1)
+	})
+
+	It("empty resources list", func() {
+		i := NewWithClient(cli)
+
+		list, err := i.BatchIsAllowedList(NewRequest("bk_paas", subject, NewAction("develop_app"), nil), nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Empty(GinkgoT(), list)
+	})
+
+	It("ctx canceled", func() {
+		i := NewWithClient(cli, WithBatchConcurrency(4))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := i.evalConcurrently(ctx, len(resourcesList), func(idx int) {
+			if idx == 0 {
+				cancel()
+			}
+		})
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+
+		// stop before the first chunk
+		i = NewWithClient(cli, WithBatchConcurrency(1))
+		calls := 0
+		err = i.evalConcurrently(ctx, len(resourcesList), func(idx int) { calls++ })
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+		assert.Zero(GinkgoT(), calls)
+
+		// the policy query is canceled
+		_, err = i.BatchIsAllowedListCtx(ctx, NewRequest("bk_paas", subject, NewAction("develop_app"), nil), resourcesList)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+	})
+})
@@ -0,0 +1,97 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/TencentBlueKing/gopkg/conv"
+)
+
+// AuthStrategy is the authentication strategy of the iam backend calls, return the headers to be set
+type AuthStrategy interface {
+	AuthHeaders() (map[string]string, error)
+}
+
+// SensitiveHeadersProvider is the optional interface of AuthStrategy, return the names of the auth headers
+// which should be removed from the logs; all the headers of AuthHeaders are removed if not implemented
+type SensitiveHeadersProvider interface {
+	SensitiveHeaders() []string
+}
+
+// APIGatewayAuth call iam backend through APIGateway, with header X-Bkapi-Authorization
+type APIGatewayAuth struct {
+	AppCode   string
+	AppSecret string
+}
+
+// AuthHeaders return the APIGateway authentication headers
+func (a APIGatewayAuth) AuthHeaders() (map[string]string, error) {
+	auth, err := json.Marshal(map[string]string{
+		"bk_app_code":   a.AppCode,
+		"bk_app_secret": a.AppSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate apigateway call header fail. err=`%s`", err)
+	}
+
+	return map[string]string{
+		"X-Bkapi-Authorization": conv.BytesToString(auth),
+	}, nil
+}
+
+// SensitiveHeaders return the header contains the app secret
+func (a APIGatewayAuth) SensitiveHeaders() []string {
+	return []string{"X-Bkapi-Authorization"}
+}
+
+// DirectAuth call iam backend directly, with header X-Bk-App-Code and X-Bk-App-Secret
+type DirectAuth struct {
+	AppCode   string
+	AppSecret string
+}
+
+// AuthHeaders return the iam backend authentication headers
+func (a DirectAuth) AuthHeaders() (map[string]string, error) {
+	return map[string]string{
+		"X-Bk-App-Code":   a.AppCode,
+		"X-Bk-App-Secret": a.AppSecret,
+	}, nil
+}
+
+// SensitiveHeaders return the header contains the app secret
+func (a DirectAuth) SensitiveHeaders() []string {
+	return []string{"X-Bk-App-Secret"}
+}
+
+// sensitiveAuthHeaders returns the names of the auth headers which should be removed from the logs
+func sensitiveAuthHeaders(auth AuthStrategy, authHeaders map[string]string) []string {
+	if p, ok := auth.(SensitiveHeadersProvider); ok {
+		return p.SensitiveHeaders()
+	}
+
+	headers := make([]string, 0, len(authHeaders))
+	for key := range authHeaders {
+		headers = append(headers, key)
+	}
+	return headers
+}
+
+// WithAuthStrategy set the authentication strategy, default is APIGatewayAuth
+func WithAuthStrategy(auth AuthStrategy) Option {
+	return func(c *iamBackendClient) {
+		if auth != nil {
+			c.auth = auth
+		}
+	}
+}
@@ -12,25 +12,31 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/TencentBlueKing/gopkg/conv"
+	"github.com/TencentBlueKing/gopkg/stringx"
 	"github.com/parnurzeal/gorequest"
 
 	"github.com/TencentBlueKing/iam-go-sdk/logger"
 	"github.com/TencentBlueKing/iam-go-sdk/util"
 )
 
-var _ IAMBackendClient = &iamBackendClient{}
+var _ ExtendedClient = &iamBackendClient{}
 
 const (
 	bkIAMVersion = "1"
+
+	// metricComponent is the component label of the metrics
+	metricComponent = "IAMBackend"
 )
 
 // Method is the type of http method
@@ -52,6 +58,8 @@ type IAMBackendBaseResponse struct {
 	Code    int             `json:"code"`
 	Message string          `json:"message"`
 	Data    json.RawMessage `json:"data"`
+	// Debug is the debug info of the request, only returned with ?debug=true
+	Debug json.RawMessage `json:"debug,omitempty"`
 }
 
 // Error will check if the response with error
@@ -69,6 +77,9 @@ func (r *IAMBackendBaseResponse) String() string {
 }
 
 // IAMBackendClient is the interface of iam backend client
+// NOTE: the method groups added later are in the separate interfaces(ContextClient, TypedClient, DebugClient
+// and AuthorizationClient), so the existing implementations of IAMBackendClient are not broken;
+// use Extend to get all of them
 type IAMBackendClient interface {
 	Ping() error
 	GetToken() (token string, err error)
@@ -113,6 +124,84 @@ type IAMBackendClient interface {
 	UpdateFeatureShieldRules(system string, body interface{}) error
 }
 
+// ContextClient is the interface of the calls with the ctx, the ctx is used for cancellation and deadline
+type ContextClient interface {
+	GetTokenCtx(ctx context.Context) (token string, err error)
+
+	V2PolicyQueryCtx(ctx context.Context, system string, body interface{}) (data map[string]interface{}, err error)
+	V2PolicyQueryByActionsCtx(
+		ctx context.Context, system string, body interface{},
+	) (data []map[string]interface{}, err error)
+	V2PolicyAuthCtx(ctx context.Context, system string, body interface{}) (data map[string]interface{}, err error)
+
+	PolicyAuthCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error)
+	PolicyAuthByResourcesCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error)
+	PolicyAuthByActionsCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error)
+
+	PolicyGetCtx(ctx context.Context, policyID int64) (data map[string]interface{}, err error)
+	PolicyListCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error)
+	PolicySubjectsCtx(ctx context.Context, policyIDs []int64) (data []map[string]interface{}, err error)
+
+	GetApplyURLCtx(ctx context.Context, body interface{}) (string, error)
+}
+
+// TypedClient is the interface of the calls with the ctx, the data is decoded into the typed responses directly
+type TypedClient interface {
+	V2PolicyQueryTypedCtx(ctx context.Context, system string, body interface{}) (result PolicyQueryResult, err error)
+	V2PolicyQueryByActionsTypedCtx(
+		ctx context.Context, system string, body interface{},
+	) (policies []ActionPolicy, err error)
+	V2PolicyAuthTypedCtx(ctx context.Context, system string, body interface{}) (result PolicyAuthResult, err error)
+
+	PolicyAuthTypedCtx(ctx context.Context, body interface{}) (result PolicyAuthResult, err error)
+	PolicyAuthByResourcesTypedCtx(ctx context.Context, body interface{}) (result map[string]bool, err error)
+	PolicyAuthByActionsTypedCtx(ctx context.Context, body interface{}) (result map[string]bool, err error)
+
+	PolicyGetTypedCtx(ctx context.Context, policyID int64) (policy Policy, err error)
+	PolicyListTypedCtx(ctx context.Context, body interface{}) (result PolicyListResult, err error)
+	PolicySubjectsTypedCtx(ctx context.Context, policyIDs []int64) (items []PolicySubjectItem, err error)
+
+	ModelQueryTypedCtx(ctx context.Context, system string) (model SystemModel, err error)
+}
+
+// DebugClient is the interface of the calls with ?debug=true&force=true, return the debug info of iam backend
+type DebugClient interface {
+	V2PolicyQueryDebugCtx(
+		ctx context.Context, system string, body interface{},
+	) (result PolicyQueryResult, debug map[string]interface{}, err error)
+}
+
+// AuthorizationClient is the interface of the grant and revoke calls
+type AuthorizationClient interface {
+	GrantResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error)
+	GrantResourceCreatorActionsCtx(ctx context.Context, body interface{}) (policies []AuthorizationPolicy, err error)
+	GrantBatchResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error)
+	GrantBatchResourceCreatorActionsCtx(
+		ctx context.Context, body interface{},
+	) (policies []AuthorizationPolicy, err error)
+	GrantOrRevokeInstancePermission(body interface{}) (policy AuthorizationPolicy, err error)
+	GrantOrRevokeInstancePermissionCtx(ctx context.Context, body interface{}) (policy AuthorizationPolicy, err error)
+	BatchGrantOrRevokeInstancePermission(body interface{}) (policies []AuthorizationPolicy, err error)
+	BatchGrantOrRevokeInstancePermissionCtx(
+		ctx context.Context, body interface{},
+	) (policies []AuthorizationPolicy, err error)
+	GrantOrRevokePathPermission(body interface{}) (policy AuthorizationPolicy, err error)
+	GrantOrRevokePathPermissionCtx(ctx context.Context, body interface{}) (policy AuthorizationPolicy, err error)
+	BatchGrantOrRevokePathPermission(body interface{}) (policies []AuthorizationPolicy, err error)
+	BatchGrantOrRevokePathPermissionCtx(
+		ctx context.Context, body interface{},
+	) (policies []AuthorizationPolicy, err error)
+}
+
+// ExtendedClient is the IAMBackendClient with all the method groups, see Extend
+type ExtendedClient interface {
+	IAMBackendClient
+	ContextClient
+	TypedClient
+	DebugClient
+	AuthorizationClient
+}
+
 type iamBackendClient struct {
 	Host string
 
@@ -124,6 +213,12 @@ type iamBackendClient struct {
 	isApiForceEnabled bool
 
 	bkTenantID string
+
+	httpClient *http.Client
+	auth       AuthStrategy
+
+	retryPolicy *RetryPolicy
+	breaker     *circuitBreaker
 }
 
 type Option func(*iamBackendClient)
@@ -134,6 +229,24 @@ func WithBkTenantID(tenantID string) Option {
 	}
 }
 
+// WithHTTPClient set the http client to call iam backend, e.g. with proxy, custom CA or connection pool settings
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *iamBackendClient) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithTransport set the http.RoundTripper to call iam backend, will override the WithHTTPClient
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *iamBackendClient) {
+		if transport != nil {
+			c.httpClient = &http.Client{Transport: transport}
+		}
+	}
+}
+
 // NewIAMBackendClient will create a iam backend client
 func NewIAMBackendClient(host string, system string, appCode string, appSecret string, opts ...Option) IAMBackendClient {
 	host = strings.TrimRight(host, "/")
@@ -148,6 +261,9 @@ func NewIAMBackendClient(host string, system string, appCode string, appSecret s
 		isApiDebugEnabled: os.Getenv("IAM_API_DEBUG") == "true" || os.Getenv("BKAPP_IAM_API_DEBUG") == "true",
 		// will add ?force=true in url, for api/policy run without cache(all data from database)
 		isApiForceEnabled: os.Getenv("IAM_API_FORCE") == "true" || os.Getenv("BKAPP_IAM_API_FORCE") == "true",
+
+		httpClient: defaultHTTPClient,
+		auth:       APIGatewayAuth{AppCode: appCode, AppSecret: appSecret},
 	}
 
 	for _, opt := range opts {
@@ -157,12 +273,118 @@ func NewIAMBackendClient(host string, system string, appCode string, appSecret s
 	return c
 }
 
+// callOptions is the options of one call
+type callOptions struct {
+	retryable bool
+	// attempt is the number of the current attempt, start from 1
+	attempt int
+	// lastRequest and lastStatusCode are the request and the status code(0 if no response) of the last attempt sent,
+	// recorded into metrics once the call is done
+	lastRequest    *http.Request
+	lastStatusCode int
+
+	// debug will add ?debug=true&force=true in url, and decode the debug info of the response into debugData
+	debug     bool
+	debugData interface{}
+}
+
+type callOption func(*callOptions)
+
+// retryable marks the call is safe to retry(no side effect), will be retried by the RetryPolicy if set
+func retryable() callOption {
+	return func(o *callOptions) {
+		o.retryable = true
+	}
+}
+
+// withDebug enables the debug and force mode of the call, the debug info of the response will be decoded into v
+func withDebug(v interface{}) callOption {
+	return func(o *callOptions) {
+		o.debug = true
+		o.debugData = v
+	}
+}
+
+// call will do the http request with retry and circuit breaker, the route is the fixed path template of the api
+// (e.g. `/api/v1/systems/{system}/policies/{policy_id}`), used as the key of the circuit breaker
 func (c *iamBackendClient) call(
-	method Method, path string,
+	ctx context.Context,
+	method Method, route, path string,
 	data interface{},
 	timeout int64,
 	responseData interface{},
+	opts ...callOption,
 ) error {
+	o := callOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	start := time.Now()
+	defer func() {
+		// the attempts without response are only recorded in the attempt metrics
+		if o.lastRequest != nil && o.lastStatusCode != 0 {
+			recordRequestMetric(metricComponent, o.lastRequest, o.lastStatusCode, start)
+		}
+	}()
+
+	maxAttempts := 1
+	if o.retryable && c.retryPolicy != nil && c.retryPolicy.MaxAttempts > 1 {
+		maxAttempts = c.retryPolicy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		o.attempt = attempt
+		statusCode, err := c.callWithBreaker(ctx, method, route, path, data, timeout, responseData, &o)
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil || !c.retryPolicy.isRetryable(statusCode, err) {
+			return err
+		}
+
+		backoff := c.retryPolicy.backoff(attempt)
+		logger.Warnf("call iam backend fail, will retry after %s [attempt=%d/%d, method=%s, path=%s, status=%d, err=%s]",
+			backoff, attempt, maxAttempts, method, path, statusCode, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry canceled: %w, last error: %s", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// callWithBreaker will do the http request once if the circuit breaker of the route allows
+func (c *iamBackendClient) callWithBreaker(
+	ctx context.Context,
+	method Method, route, path string,
+	data interface{},
+	timeout int64,
+	responseData interface{},
+	o *callOptions,
+) (int, error) {
+	if c.breaker == nil {
+		return c.callOnce(ctx, method, path, data, timeout, responseData, o)
+	}
+
+	generation, err := c.breaker.allow(route)
+	if err != nil {
+		return 0, err
+	}
+	statusCode, err := c.callOnce(ctx, method, path, data, timeout, responseData, o)
+	c.breaker.done(route, generation, statusCode, err)
+	return statusCode, err
+}
+
+// callOnce will do the http request once, return the http status code(0 if no response) and the error
+func (c *iamBackendClient) callOnce(
+	ctx context.Context,
+	method Method, path string,
+	data interface{},
+	timeout int64,
+	responseData interface{},
+	o *callOptions,
+) (int, error) {
 	callTimeout := time.Duration(timeout) * time.Second
 	if timeout == 0 {
 		callTimeout = defaultTimeout
@@ -172,16 +394,14 @@ func (c *iamBackendClient) call(
 		"X-Bk-IAM-Version": bkIAMVersion,
 	}
 
-	// APIGateway Authentication
-	auth, err := json.Marshal(map[string]string{
-		"bk_app_code":   c.appCode,
-		"bk_app_secret": c.appSecret,
-	})
+	// Authentication
+	authHeaders, err := c.auth.AuthHeaders()
 	if err != nil {
-		return fmt.Errorf("generate apigateway call header fail. err=`%s`", err)
+		return 0, err
+	}
+	for key, value := range authHeaders {
+		headers[key] = value
 	}
-
-	headers["X-Bkapi-Authorization"] = conv.BytesToString(auth)
 
 	// BK Tenant ID
 	if c.bkTenantID != "" {
@@ -190,12 +410,14 @@ func (c *iamBackendClient) call(
 
 	url := fmt.Sprintf("%s%s", c.Host, path)
 	start := time.Now()
-	callbackFunc := NewMetricCallback("IAMBackend", start)
 
 	logger.Debugf("do http request: method=`%s`, url=`%s`, data=`%s`", method, url, data)
 
-	// request := gorequest.New().Timeout(callTimeout).Post(url).Type("json")
-	request := gorequest.New().Timeout(callTimeout).Type("json")
+	// the deadline of ctx will take precedence if it is earlier than the call timeout
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	request := gorequest.New().Type("json")
 	switch method {
 	case POST:
 		request = request.Post(url).Send(data)
@@ -207,10 +429,10 @@ func (c *iamBackendClient) call(
 		request = request.Delete(url).Send(data)
 	}
 
-	if c.isApiDebugEnabled {
+	if c.isApiDebugEnabled || o.debug {
 		request.QueryData.Add("debug", "true")
 	}
-	if c.isApiForceEnabled {
+	if c.isApiForceEnabled || o.debug {
 		request.QueryData.Add("force", "true")
 	}
 
@@ -221,12 +443,18 @@ func (c *iamBackendClient) call(
 
 	// do request
 	baseResult := IAMBackendBaseResponse{}
-	resp, respBody, errs := request.
-		EndStruct(&baseResult, callbackFunc)
-
-	if len(errs) != 0 {
-		logFailHTTPRequest(request, resp, respBody, errs, &baseResult)
-		return fmt.Errorf("gorequest errors=`%s`", errs)
+	resp, respBody, err := c.doRequest(ctx, request, &baseResult, o, start)
+	if err != nil {
+		logFailHTTPRequest(request, resp, respBody, []error{err}, &baseResult, sensitiveAuthHeaders(c.auth, authHeaders))
+		if resp == nil {
+			return 0, fmt.Errorf("http request fail: %w", err)
+		}
+		// the response body is not a valid json, e.g. 502 from APIGateway
+		if resp.StatusCode != http.StatusOK {
+			message := stringx.Truncate(conv.BytesToString(respBody), maxErrorMessageLength)
+			return resp.StatusCode, newAPIError(method, path, resp, 0, message)
+		}
+		return resp.StatusCode, fmt.Errorf("http request response body not valid: %w", err)
 	}
 
 	body := ""
@@ -239,33 +467,77 @@ func (c *iamBackendClient) call(
 	logger.Debugf("http request took %v ms", float64(duration/time.Millisecond))
 	logger.Debugf("http response: status_code=%s, body=%+v", resp.StatusCode, body)
 
-	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("gorequest statusCode is %d not 200", resp.StatusCode)
-		if baseResult.Message != "" {
-			err = fmt.Errorf("%w. response body.code: %d, message:%s", err, baseResult.Code, baseResult.Message)
+	if resp.StatusCode != http.StatusOK || baseResult.Code != 0 {
+		return resp.StatusCode, newAPIError(method, path, resp, baseResult.Code, baseResult.Message)
+	}
+
+	err = json.Unmarshal(baseResult.Data, responseData)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("http request response body data not valid: %w, data=`%v`", err, baseResult.Data)
+	}
+
+	if o.debugData != nil && len(baseResult.Debug) != 0 {
+		err = json.Unmarshal(baseResult.Debug, o.debugData)
+		if err != nil {
+			return resp.StatusCode, fmt.Errorf("http request response body debug not valid: %w, debug=`%s`",
+				err, baseResult.Debug)
 		}
+	}
+	return resp.StatusCode, nil
+}
 
-		return err
+// doRequest will send the request built by gorequest with the ctx, and decode the response body into v;
+// every attempt sent is recorded into the attempt metrics, including the ones without response
+// NOTE: gorequest does not support context and creates a new http client for each request,
+// so here we make the http.Request by gorequest and send it by the shared http client
+func (c *iamBackendClient) doRequest(
+	ctx context.Context,
+	request *gorequest.SuperAgent,
+	v interface{},
+	o *callOptions,
+	start time.Time,
+) (gorequest.Response, []byte, error) {
+	if len(request.Errors) != 0 {
+		return nil, nil, request.Errors[0]
 	}
 
-	if baseResult.Code != 0 {
-		return fmt.Errorf("response body.code: %d, message:%s", baseResult.Code, baseResult.Message)
+	req, err := request.MakeRequest()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	err = json.Unmarshal(baseResult.Data, responseData)
+	o.lastRequest, o.lastStatusCode = req, 0
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("http request response body data not valid: %w, data=`%v`", err, baseResult.Data)
+		recordAttemptMetric(metricComponent, req, nil, o.attempt, start)
+		return nil, nil, err
 	}
-	return nil
+	defer resp.Body.Close()
+
+	// record all the responses, including the non-json ones(e.g. 502 from APIGateway) which will be retried
+	body, err := io.ReadAll(resp.Body)
+	o.lastStatusCode = resp.StatusCode
+	recordAttemptMetric(metricComponent, req, resp, o.attempt, start)
+	if err != nil {
+		return resp, nil, err
+	}
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return resp, body, err
+	}
+	return resp, body, nil
 }
 
 func (c *iamBackendClient) callWithReturnMapData(
-	method Method, path string,
+	ctx context.Context,
+	method Method, route, path string,
 	data interface{},
 	timeout int64,
+	opts ...callOption,
 ) (map[string]interface{}, error) {
 	var responseData map[string]interface{}
-	err := c.call(method, path, data, timeout, &responseData)
+	err := c.call(ctx, method, route, path, data, timeout, &responseData, opts...)
 	if err != nil {
 		return map[string]interface{}{}, err
 	}
@@ -273,12 +545,14 @@ func (c *iamBackendClient) callWithReturnMapData(
 }
 
 func (c *iamBackendClient) callWithReturnSliceMapData(
-	method Method, path string,
+	ctx context.Context,
+	method Method, route, path string,
 	data interface{},
 	timeout int64,
+	opts ...callOption,
 ) ([]map[string]interface{}, error) {
 	var responseData []map[string]interface{}
-	err := c.call(method, path, data, timeout, &responseData)
+	err := c.call(ctx, method, route, path, data, timeout, &responseData, opts...)
 	if err != nil {
 		return []map[string]interface{}{}, err
 	}
@@ -289,132 +563,408 @@ func (c *iamBackendClient) callWithReturnSliceMapData(
 func (c *iamBackendClient) Ping() (err error) {
 	url := fmt.Sprintf("%s%s", c.Host, "/ping")
 
-	resp, _, errs := gorequest.New().Timeout(defaultTimeout).Get(url).EndBytes()
-	if len(errs) != 0 {
-		return fmt.Errorf("ping fail! errs=%v", errs)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("ping fail! err=%w", err)
 	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ping fail! err=%w", err)
+	}
+	defer resp.Body.Close()
+	// drain the body, so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ping fail! status_code=%d", resp.StatusCode)
+		return fmt.Errorf("ping fail! %w", newAPIError(GET, "/ping", resp, 0, http.StatusText(resp.StatusCode)))
 	}
 	return nil
 }
 
 // GetToken will get the token of system, use for callback requests basic auth
 func (c *iamBackendClient) GetToken() (token string, err error) {
+	return c.GetTokenCtx(context.Background())
+}
+
+// GetTokenCtx will get the token of system with the ctx, use for callback requests basic auth
+func (c *iamBackendClient) GetTokenCtx(ctx context.Context) (token string, err error) {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/token", c.System)
-	data, err := c.callWithReturnMapData(GET, path, map[string]interface{}{}, 10)
+	var data Token
+	err = c.call(ctx, GET, "/api/v1/model/systems/{system}/token", path, map[string]interface{}{}, 10, &data, retryable())
 	if err != nil {
 		return "", err
 	}
-	tokenI, ok := data["token"]
-	if !ok {
+	if data.Token == "" {
 		return "", errors.New("no token in response body")
 	}
-	token, ok = tokenI.(string)
-	if !ok {
-		return "", errors.New("token is not a valid string")
-	}
-	return token, nil
+	return data.Token, nil
 }
 
 // PolicyQuery will do policy query
 func (c *iamBackendClient) PolicyQuery(body interface{}) (data map[string]interface{}, err error) {
 	path := "/api/v1/policy/query"
-	data, err = c.callWithReturnMapData(POST, path, body, 10)
+	data, err = c.callWithReturnMapData(context.Background(), POST, "/api/v1/policy/query", path, body, 10, retryable())
 	return
 }
 
 // V2PolicyQuery will do policy query
 func (c *iamBackendClient) V2PolicyQuery(system string, body interface{}) (data map[string]interface{}, err error) {
+	return c.V2PolicyQueryCtx(context.Background(), system, body)
+}
+
+// V2PolicyQueryCtx will do policy query with the ctx
+func (c *iamBackendClient) V2PolicyQueryCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	path := "/api/v2/policy/systems/" + system + "/query/"
+	data, err = c.callWithReturnMapData(ctx, POST, "/api/v2/policy/systems/{system}/query/", path, body, 10, retryable())
+	return
+}
+
+// V2PolicyQueryTypedCtx will do policy query with the ctx, the data is decoded into PolicyQueryResult directly
+func (c *iamBackendClient) V2PolicyQueryTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyQueryResult, err error) {
 	path := "/api/v2/policy/systems/" + system + "/query/"
-	data, err = c.callWithReturnMapData(POST, path, body, 10)
+	err = c.call(ctx, POST, "/api/v2/policy/systems/{system}/query/", path, body, 10, &result, retryable())
+	return
+}
+
+// V2PolicyQueryDebugCtx will do policy query with ?debug=true&force=true, return the result and the debug info
+func (c *iamBackendClient) V2PolicyQueryDebugCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyQueryResult, debug map[string]interface{}, err error) {
+	path := "/api/v2/policy/systems/" + system + "/query/"
+	err = c.call(ctx, POST, "/api/v2/policy/systems/{system}/query/", path, body, 10, &result, retryable(), withDebug(&debug))
 	return
 }
 
 // PolicyQueryByActions will do policy query by actions
 func (c *iamBackendClient) PolicyQueryByActions(body interface{}) (data []map[string]interface{}, err error) {
 	path := "/api/v1/policy/query_by_actions"
-	data, err = c.callWithReturnSliceMapData(POST, path, body, 10)
+	data, err = c.callWithReturnSliceMapData(context.Background(), POST, "/api/v1/policy/query_by_actions", path, body, 10, retryable())
 	return
 }
 
 // V2PolicyQueryByActions will do policy query by actions
 func (c *iamBackendClient) V2PolicyQueryByActions(system string, body interface{}) (data []map[string]interface{}, err error) {
+	return c.V2PolicyQueryByActionsCtx(context.Background(), system, body)
+}
+
+// V2PolicyQueryByActionsCtx will do policy query by actions with the ctx
+func (c *iamBackendClient) V2PolicyQueryByActionsCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data []map[string]interface{}, err error) {
 	path := "/api/v2/policy/systems/" + system + "/query_by_actions/"
-	data, err = c.callWithReturnSliceMapData(POST, path, body, 10)
+	data, err = c.callWithReturnSliceMapData(ctx, POST, "/api/v2/policy/systems/{system}/query_by_actions/", path, body, 10, retryable())
+	return
+}
+
+// V2PolicyQueryByActionsTypedCtx will do policy query by actions with the ctx,
+// the data is decoded into []ActionPolicy directly
+func (c *iamBackendClient) V2PolicyQueryByActionsTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (policies []ActionPolicy, err error) {
+	path := "/api/v2/policy/systems/" + system + "/query_by_actions/"
+	err = c.call(ctx, POST, "/api/v2/policy/systems/{system}/query_by_actions/", path, body, 10, &policies, retryable())
 	return
 }
 
 // PolicyAuth will do policy auth
 func (c *iamBackendClient) PolicyAuth(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyAuthCtx(context.Background(), body)
+}
+
+// PolicyAuthCtx will do policy auth with the ctx
+func (c *iamBackendClient) PolicyAuthCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error) {
 	path := "/api/v1/policy/auth"
-	data, err = c.callWithReturnMapData(POST, path, body, 10)
+	data, err = c.callWithReturnMapData(ctx, POST, "/api/v1/policy/auth", path, body, 10, retryable())
+	return
+}
+
+// PolicyAuthTypedCtx will do policy auth with the ctx, the data is decoded into PolicyAuthResult directly
+func (c *iamBackendClient) PolicyAuthTypedCtx(ctx context.Context, body interface{}) (result PolicyAuthResult, err error) {
+	path := "/api/v1/policy/auth"
+	err = c.call(ctx, POST, "/api/v1/policy/auth", path, body, 10, &result, retryable())
 	return
 }
 
 // V2PolicyAuth will do policy auth
 func (c *iamBackendClient) V2PolicyAuth(system string, body interface{}) (data map[string]interface{}, err error) {
+	return c.V2PolicyAuthCtx(context.Background(), system, body)
+}
+
+// V2PolicyAuthCtx will do policy auth with the ctx
+func (c *iamBackendClient) V2PolicyAuthCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	path := "/api/v2/policy/systems/" + system + "/auth/"
+	data, err = c.callWithReturnMapData(ctx, POST, "/api/v2/policy/systems/{system}/auth/", path, body, 10, retryable())
+	return
+}
+
+// V2PolicyAuthTypedCtx will do policy auth with the ctx, the data is decoded into PolicyAuthResult directly
+func (c *iamBackendClient) V2PolicyAuthTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyAuthResult, err error) {
 	path := "/api/v2/policy/systems/" + system + "/auth/"
-	data, err = c.callWithReturnMapData(POST, path, body, 10)
+	err = c.call(ctx, POST, "/api/v2/policy/systems/{system}/auth/", path, body, 10, &result, retryable())
 	return
 }
 
 // PolicyAuthByResources will do policy auth by resources
 func (c *iamBackendClient) PolicyAuthByResources(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyAuthByResourcesCtx(context.Background(), body)
+}
+
+// PolicyAuthByResourcesCtx will do policy auth by resources with the ctx
+func (c *iamBackendClient) PolicyAuthByResourcesCtx(
+	ctx context.Context,
+	body interface{},
+) (data map[string]interface{}, err error) {
 	path := "/api/v1/policy/auth_by_resources"
-	data, err = c.callWithReturnMapData(POST, path, body, 10)
+	data, err = c.callWithReturnMapData(ctx, POST, "/api/v1/policy/auth_by_resources", path, body, 10, retryable())
+	return
+}
+
+// PolicyAuthByResourcesTypedCtx will do policy auth by resources with the ctx,
+// the data is decoded into the allowed of each resource id directly
+func (c *iamBackendClient) PolicyAuthByResourcesTypedCtx(
+	ctx context.Context,
+	body interface{},
+) (result map[string]bool, err error) {
+	path := "/api/v1/policy/auth_by_resources"
+	err = c.call(ctx, POST, "/api/v1/policy/auth_by_resources", path, body, 10, &result, retryable())
 	return
 }
 
 // PolicyAuthByActions will do policy auth by actions
 func (c *iamBackendClient) PolicyAuthByActions(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyAuthByActionsCtx(context.Background(), body)
+}
+
+// PolicyAuthByActionsCtx will do policy auth by actions with the ctx
+func (c *iamBackendClient) PolicyAuthByActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	path := "/api/v1/policy/auth_by_actions"
+	data, err = c.callWithReturnMapData(ctx, POST, "/api/v1/policy/auth_by_actions", path, body, 10, retryable())
+	return
+}
+
+// PolicyAuthByActionsTypedCtx will do policy auth by actions with the ctx,
+// the data is decoded into the allowed of each action id directly
+func (c *iamBackendClient) PolicyAuthByActionsTypedCtx(
+	ctx context.Context,
+	body interface{},
+) (result map[string]bool, err error) {
 	path := "/api/v1/policy/auth_by_actions"
-	data, err = c.callWithReturnMapData(POST, path, body, 10)
+	err = c.call(ctx, POST, "/api/v1/policy/auth_by_actions", path, body, 10, &result, retryable())
 	return
 }
 
 // PolicyGet will get the policy detail by id
 func (c *iamBackendClient) PolicyGet(policyID int64) (data map[string]interface{}, err error) {
+	return c.PolicyGetCtx(context.Background(), policyID)
+}
+
+// PolicyGetCtx will get the policy detail by id with the ctx
+func (c *iamBackendClient) PolicyGetCtx(ctx context.Context, policyID int64) (data map[string]interface{}, err error) {
 	path := fmt.Sprintf("/api/v1/systems/%s/policies/%d", c.System, policyID)
-	data, err = c.callWithReturnMapData(GET, path, map[string]interface{}{}, 10)
+	data, err = c.callWithReturnMapData(ctx, GET, "/api/v1/systems/{system}/policies/{policy_id}", path, map[string]interface{}{}, 10, retryable())
+	return
+}
+
+// PolicyGetTypedCtx will get the policy detail by id with the ctx, the data is decoded into Policy directly
+func (c *iamBackendClient) PolicyGetTypedCtx(ctx context.Context, policyID int64) (policy Policy, err error) {
+	path := fmt.Sprintf("/api/v1/systems/%s/policies/%d", c.System, policyID)
+	err = c.call(ctx, GET, "/api/v1/systems/{system}/policies/{policy_id}", path, map[string]interface{}{}, 10, &policy, retryable())
 	return
 }
 
 // PolicyList will list all the policy
 func (c *iamBackendClient) PolicyList(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyListCtx(context.Background(), body)
+}
+
+// PolicyListCtx will list all the policy with the ctx
+func (c *iamBackendClient) PolicyListCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error) {
+	path := fmt.Sprintf("/api/v1/systems/%s/policies", c.System)
+	data, err = c.callWithReturnMapData(ctx, GET, "/api/v1/systems/{system}/policies", path, body, 10, retryable())
+	return
+}
+
+// PolicyListTypedCtx will list the policies with the ctx, the data is decoded into PolicyListResult directly
+func (c *iamBackendClient) PolicyListTypedCtx(ctx context.Context, body interface{}) (result PolicyListResult, err error) {
 	path := fmt.Sprintf("/api/v1/systems/%s/policies", c.System)
-	data, err = c.callWithReturnMapData(GET, path, body, 10)
+	err = c.call(ctx, GET, "/api/v1/systems/{system}/policies", path, body, 10, &result, retryable())
 	return
 }
 
 // PolicySubjects will query the subject of each policy
 func (c *iamBackendClient) PolicySubjects(policyIDs []int64) (data []map[string]interface{}, err error) {
+	return c.PolicySubjectsCtx(context.Background(), policyIDs)
+}
+
+// PolicySubjectsCtx will query the subject of each policy with the ctx
+func (c *iamBackendClient) PolicySubjectsCtx(
+	ctx context.Context,
+	policyIDs []int64,
+) (data []map[string]interface{}, err error) {
 	path := fmt.Sprintf("/api/v1/systems/%s/policies/-/subjects", c.System)
 
 	body := map[string]interface{}{
 		"ids": util.Int64ArrayToString(policyIDs, ","),
 	}
-	data, err = c.callWithReturnSliceMapData(GET, path, body, 10)
+	data, err = c.callWithReturnSliceMapData(ctx, GET, "/api/v1/systems/{system}/policies/-/subjects", path, body, 10, retryable())
+	return
+}
+
+// PolicySubjectsTypedCtx will query the subject of each policy with the ctx,
+// the data is decoded into []PolicySubjectItem directly
+func (c *iamBackendClient) PolicySubjectsTypedCtx(
+	ctx context.Context,
+	policyIDs []int64,
+) (items []PolicySubjectItem, err error) {
+	path := fmt.Sprintf("/api/v1/systems/%s/policies/-/subjects", c.System)
+
+	body := map[string]interface{}{
+		"ids": util.Int64ArrayToString(policyIDs, ","),
+	}
+	err = c.call(ctx, GET, "/api/v1/systems/{system}/policies/-/subjects", path, body, 10, &items, retryable())
 	return
 }
 
 // GetApplyURL will get apply url from iam saas
 func (c *iamBackendClient) GetApplyURL(body interface{}) (url string, err error) {
+	return c.GetApplyURLCtx(context.Background(), body)
+}
+
+// GetApplyURLCtx will get apply url from iam saas with the ctx
+func (c *iamBackendClient) GetApplyURLCtx(ctx context.Context, body interface{}) (url string, err error) {
 	path := "/api/v1/open/application/"
-	data, err := c.callWithReturnMapData(POST, path, body, 10)
+	var data ApplyURL
+	err = c.call(ctx, POST, "/api/v1/open/application/", path, body, 10, &data)
 	if err != nil {
 		return "", err
 	}
-
-	urlI, ok := data["url"]
-	if !ok {
+	if data.URL == "" {
 		return "", errors.New("no url in response body")
 	}
-	url, ok = urlI.(string)
-	if !ok {
-		return "", errors.New("url is not a valid string")
-	}
-	return url, nil
+	return data.URL, nil
+}
+
+// GrantResourceCreatorActions will grant the resource creator the actions configured in resource_creator_actions
+func (c *iamBackendClient) GrantResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.GrantResourceCreatorActionsCtx(context.Background(), body)
+}
+
+// GrantResourceCreatorActionsCtx will grant the resource creator the actions with the ctx
+func (c *iamBackendClient) GrantResourceCreatorActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	path := "/api/v1/open/authorization/resource_creator_action/"
+	err = c.call(ctx, POST, "/api/v1/open/authorization/resource_creator_action/", path, body, 10, &policies)
+	return
+}
+
+// GrantBatchResourceCreatorActions will grant the creator of batch resources the actions
+func (c *iamBackendClient) GrantBatchResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.GrantBatchResourceCreatorActionsCtx(context.Background(), body)
+}
+
+// GrantBatchResourceCreatorActionsCtx will grant the creator of batch resources the actions with the ctx
+func (c *iamBackendClient) GrantBatchResourceCreatorActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	path := "/api/v1/open/authorization/batch_resource_creator_action/"
+	err = c.call(ctx, POST, "/api/v1/open/authorization/batch_resource_creator_action/", path, body, 10, &policies)
+	return
+}
+
+// GrantOrRevokeInstancePermission will grant or revoke the permission of the action on the resource instances
+func (c *iamBackendClient) GrantOrRevokeInstancePermission(body interface{}) (policy AuthorizationPolicy, err error) {
+	return c.GrantOrRevokeInstancePermissionCtx(context.Background(), body)
+}
+
+// GrantOrRevokeInstancePermissionCtx will grant or revoke the permission of the resource instances with the ctx
+func (c *iamBackendClient) GrantOrRevokeInstancePermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policy AuthorizationPolicy, err error) {
+	path := "/api/v1/open/authorization/instance/"
+	err = c.call(ctx, POST, "/api/v1/open/authorization/instance/", path, body, 10, &policy)
+	return
+}
+
+// BatchGrantOrRevokeInstancePermission will grant or revoke the permissions of the actions on the resource instances
+func (c *iamBackendClient) BatchGrantOrRevokeInstancePermission(
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	return c.BatchGrantOrRevokeInstancePermissionCtx(context.Background(), body)
+}
+
+// BatchGrantOrRevokeInstancePermissionCtx will grant or revoke the permissions in batch with the ctx
+func (c *iamBackendClient) BatchGrantOrRevokeInstancePermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	path := "/api/v1/open/authorization/batch_instance/"
+	err = c.call(ctx, POST, "/api/v1/open/authorization/batch_instance/", path, body, 10, &policies)
+	return
+}
+
+// GrantOrRevokePathPermission will grant or revoke the permission of the action on the topology paths
+func (c *iamBackendClient) GrantOrRevokePathPermission(body interface{}) (policy AuthorizationPolicy, err error) {
+	return c.GrantOrRevokePathPermissionCtx(context.Background(), body)
+}
+
+// GrantOrRevokePathPermissionCtx will grant or revoke the permission of the topology paths with the ctx
+func (c *iamBackendClient) GrantOrRevokePathPermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policy AuthorizationPolicy, err error) {
+	path := "/api/v1/open/authorization/path/"
+	err = c.call(ctx, POST, "/api/v1/open/authorization/path/", path, body, 10, &policy)
+	return
+}
+
+// BatchGrantOrRevokePathPermission will grant or revoke the permissions of the actions on the topology paths
+func (c *iamBackendClient) BatchGrantOrRevokePathPermission(
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	return c.BatchGrantOrRevokePathPermissionCtx(context.Background(), body)
+}
+
+// BatchGrantOrRevokePathPermissionCtx will grant or revoke the permissions of the topology paths in batch with the ctx
+func (c *iamBackendClient) BatchGrantOrRevokePathPermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	path := "/api/v1/open/authorization/batch_path/"
+	err = c.call(ctx, POST, "/api/v1/open/authorization/batch_path/", path, body, 10, &policies)
+	return
 }
 
 // ModelQuery performs a model query using the specified system.
@@ -426,7 +976,17 @@ func (c *iamBackendClient) ModelQuery(system string) (map[string]interface{}, er
 		system = c.System
 	}
 	path := fmt.Sprintf("/api/v1/model/systems/%s/query", system)
-	return c.callWithReturnMapData(GET, path, map[string]interface{}{}, 10)
+	return c.callWithReturnMapData(context.Background(), GET, "/api/v1/model/systems/{system}/query", path, map[string]interface{}{}, 10, retryable())
+}
+
+// ModelQueryTypedCtx will query the model of the system with the ctx, the data is decoded into SystemModel directly
+func (c *iamBackendClient) ModelQueryTypedCtx(ctx context.Context, system string) (model SystemModel, err error) {
+	if system == "" {
+		system = c.System
+	}
+	path := fmt.Sprintf("/api/v1/model/systems/%s/query", system)
+	err = c.call(ctx, GET, "/api/v1/model/systems/{system}/query", path, map[string]interface{}{}, 10, &model, retryable())
+	return
 }
 
 // AddSystem is a function that adds a system to the IAM backend.
@@ -435,7 +995,7 @@ func (c *iamBackendClient) ModelQuery(system string) (map[string]interface{}, er
 // It returns an error if the operation fails.
 func (c *iamBackendClient) AddSystem(body interface{}) error {
 	path := "/api/v1/model/systems"
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems", path, body, 10)
 	return err
 }
 
@@ -446,7 +1006,7 @@ func (c *iamBackendClient) AddSystem(body interface{}) error {
 // error: An error if the update operation fails.
 func (c *iamBackendClient) UpdateSystem(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s", system)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}", path, body, 10)
 	return err
 }
 
@@ -462,7 +1022,7 @@ func (c *iamBackendClient) UpdateSystem(system string, body interface{}) error {
 // - error: An error if the operation fails.
 func (c *iamBackendClient) AddResourceType(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/resource-types", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/resource-types", path, body, 10)
 	return err
 }
 
@@ -477,7 +1037,7 @@ func (c *iamBackendClient) AddResourceType(system string, body interface{}) erro
 //   - error: an error if the update fails
 func (c *iamBackendClient) UpdateResourceType(system, resourceTypeID string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/resource-types/%s", system, resourceTypeID)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/resource-types/{resource_type_id}", path, body, 10)
 	return err
 }
 
@@ -497,7 +1057,7 @@ func (c *iamBackendClient) BatchDeleteResourceType(system string, resourceTypeID
 			"id": v,
 		})
 	}
-	_, err := c.callWithReturnMapData(DELETE, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), DELETE, "/api/v1/model/systems/{system}/resource-types", path, body, 10)
 	return err
 }
 
@@ -513,7 +1073,7 @@ func (c *iamBackendClient) BatchDeleteResourceType(system string, resourceTypeID
 // - error: An error if the operation fails.
 func (c *iamBackendClient) AddInstanceSelection(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/instance-selections", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/instance-selections", path, body, 10)
 	return err
 }
 
@@ -525,7 +1085,7 @@ func (c *iamBackendClient) AddInstanceSelection(system string, body interface{})
 // Returns an error if the update fails.
 func (c *iamBackendClient) UpdateInstanceSelection(system, instanceSelectionID string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/instance-selections/%s", system, instanceSelectionID)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/instance-selections/{instance_selection_id}", path, body, 10)
 	return err
 }
 
@@ -548,7 +1108,7 @@ func (c *iamBackendClient) BatchDeleteInstanceSelection(system string, instanceS
 			"id": v,
 		})
 	}
-	_, err := c.callWithReturnMapData(DELETE, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), DELETE, "/api/v1/model/systems/{system}/instance-selections", path, body, 10)
 	return err
 }
 
@@ -559,7 +1119,7 @@ func (c *iamBackendClient) BatchDeleteInstanceSelection(system string, instanceS
 // error: an error, if any, encountered during the process.
 func (c *iamBackendClient) AddAction(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/actions", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/actions", path, body, 10)
 	return err
 }
 
@@ -571,7 +1131,7 @@ func (c *iamBackendClient) AddAction(system string, body interface{}) error {
 // Returns an error if the update fails.
 func (c *iamBackendClient) UpdateAction(system, actionID string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/actions/%s", system, actionID)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/actions/{action_id}", path, body, 10)
 	return err
 }
 
@@ -590,7 +1150,7 @@ func (c *iamBackendClient) BatchDeleteAction(system string, actionIDs ...string)
 			"id": v,
 		})
 	}
-	_, err := c.callWithReturnMapData(DELETE, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), DELETE, "/api/v1/model/systems/{system}/actions", path, body, 10)
 	return err
 }
 
@@ -603,7 +1163,7 @@ func (c *iamBackendClient) BatchDeleteAction(system string, actionIDs ...string)
 // It returns an error.
 func (c *iamBackendClient) AddActionGroups(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/action_groups", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/configs/action_groups", path, body, 10)
 	return err
 }
 
@@ -616,7 +1176,7 @@ func (c *iamBackendClient) AddActionGroups(system string, body interface{}) erro
 // It returns an error indicating any issues encountered during the update process.
 func (c *iamBackendClient) UpdateActionGroups(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/action_groups", system)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/configs/action_groups", path, body, 10)
 	return err
 }
 
@@ -629,7 +1189,7 @@ func (c *iamBackendClient) UpdateActionGroups(system string, body interface{}) e
 // It returns an error.
 func (c *iamBackendClient) AddResourceCreatorActions(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/resource_creator_actions", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/configs/resource_creator_actions", path, body, 10)
 	return err
 }
 
@@ -640,7 +1200,7 @@ func (c *iamBackendClient) AddResourceCreatorActions(system string, body interfa
 // Return type: error.
 func (c *iamBackendClient) UpdateResourceCreatorActions(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/resource_creator_actions", system)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/configs/resource_creator_actions", path, body, 10)
 	return err
 }
 
@@ -651,7 +1211,7 @@ func (c *iamBackendClient) UpdateResourceCreatorActions(system string, body inte
 // error: An error that occurred during the function execution, if any.
 func (c *iamBackendClient) AddCommonActions(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/common_actions", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/configs/common_actions", path, body, 10)
 	return err
 }
 
@@ -662,7 +1222,7 @@ func (c *iamBackendClient) AddCommonActions(system string, body interface{}) err
 // error: an error if the update fails.
 func (c *iamBackendClient) UpdateCommonActions(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/common_actions", system)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/configs/common_actions", path, body, 10)
 	return err
 }
 
@@ -673,7 +1233,7 @@ func (c *iamBackendClient) UpdateCommonActions(system string, body interface{})
 // Returns an error if there was a problem adding the rules.
 func (c *iamBackendClient) AddFeatureShieldRules(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/feature_shield_rules", system)
-	_, err := c.callWithReturnMapData(POST, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), POST, "/api/v1/model/systems/{system}/configs/feature_shield_rules", path, body, 10)
 	return err
 }
 
@@ -687,6 +1247,6 @@ func (c *iamBackendClient) AddFeatureShieldRules(system string, body interface{}
 //   - error: an error if the update fails.
 func (c *iamBackendClient) UpdateFeatureShieldRules(system string, body interface{}) error {
 	path := fmt.Sprintf("/api/v1/model/systems/%s/configs/feature_shield_rules", system)
-	_, err := c.callWithReturnMapData(PUT, path, body, 10)
+	_, err := c.callWithReturnMapData(context.Background(), PUT, "/api/v1/model/systems/{system}/configs/feature_shield_rules", path, body, 10)
 	return err
 }
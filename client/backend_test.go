@@ -0,0 +1,584 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+	"github.com/TencentBlueKing/iam-go-sdk/logger"
+	"github.com/TencentBlueKing/iam-go-sdk/metric"
+)
+
+// requestMetricCount returns the sample count of the request duration metric with the labels
+func requestMetricCount(path, status string) uint64 {
+	m := &dto.Metric{}
+	h := metric.ClientRequestDuration.WithLabelValues("GET", path, status, "IAMBackend")
+	_ = h.(interface{ Write(*dto.Metric) error }).Write(m)
+	return m.GetHistogram().GetSampleCount()
+}
+
+// attemptMetricCount returns the sample count of the request attempt duration metric with the labels
+func attemptMetricCount(path, status, attempt string) uint64 {
+	m := &dto.Metric{}
+	h := metric.ClientRequestAttemptDuration.WithLabelValues("GET", path, status, "IAMBackend", attempt)
+	_ = h.(interface{ Write(*dto.Metric) error }).Write(m)
+	return m.GetHistogram().GetSampleCount()
+}
+
+// tokenAuth is a custom auth strategy without SensitiveHeaders
+type tokenAuth map[string]string
+
+func (a tokenAuth) AuthHeaders() (map[string]string, error) {
+	return a, nil
+}
+
+type countingTransport struct {
+	count int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.count, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("Backend", func() {
+	var ts *httptest.Server
+	var cli client.ExtendedClient
+
+	BeforeEach(func() {
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"token": "abc"}}`))
+		}))
+		cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret"))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("GetTokenCtx", func() {
+		It("ok", func() {
+			token, err := cli.GetTokenCtx(context.Background())
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "abc", token)
+		})
+
+		It("canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := cli.GetTokenCtx(ctx)
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, context.Canceled))
+		})
+	})
+
+	Context("V2PolicyQueryCtx", func() {
+		It("deadline exceeded", func() {
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err := cli.V2PolicyQueryCtx(ctx, "test", map[string]interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, context.DeadlineExceeded))
+		})
+	})
+
+	Context("WithTransport", func() {
+		It("use the transport", func() {
+			transport := &countingTransport{}
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithTransport(transport)))
+
+			_, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.NoError(GinkgoT(), cli.Ping())
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&transport.count))
+		})
+	})
+
+	Context("WithHTTPClient", func() {
+		It("use the http client", func() {
+			transport := &countingTransport{}
+			httpClient := &http.Client{Transport: transport}
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithHTTPClient(httpClient)))
+
+			_, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int32(1), atomic.LoadInt32(&transport.count))
+		})
+	})
+
+	Context("WithRetryPolicy", func() {
+		var count int32
+		var policy client.RetryPolicy
+
+		BeforeEach(func() {
+			count = 0
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&count, 1) == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+					_, _ = w.Write([]byte("<html>bad gateway</html>"))
+					return
+				}
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"token": "abc"}}`))
+			})
+
+			policy = client.DefaultRetryPolicy()
+			policy.InitialBackoff = time.Millisecond
+		})
+
+		It("retry the safe call", func() {
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithRetryPolicy(policy)))
+
+			token, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "abc", token)
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&count))
+		})
+
+		It("record every attempt into the attempt metrics", func() {
+			path := "/api/v1/model/systems/test/token"
+			before502 := attemptMetricCount(path, "502", "1")
+			before200 := attemptMetricCount(path, "200", "2")
+			beforeRequest502 := requestMetricCount(path, "502")
+			beforeRequest200 := requestMetricCount(path, "200")
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithRetryPolicy(policy)))
+
+			_, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), before502+1, attemptMetricCount(path, "502", "1"))
+			assert.Equal(GinkgoT(), before200+1, attemptMetricCount(path, "200", "2"))
+
+			// the request is recorded once with the result of the last attempt
+			assert.Equal(GinkgoT(), beforeRequest502, requestMetricCount(path, "502"))
+			assert.Equal(GinkgoT(), beforeRequest200+1, requestMetricCount(path, "200"))
+		})
+
+		It("record the attempts without response into the attempt metrics", func() {
+			closed := httptest.NewServer(http.NotFoundHandler())
+			closed.Close()
+
+			path := "/api/v1/model/systems/test/token"
+			before := []uint64{
+				attemptMetricCount(path, "error", "1"),
+				attemptMetricCount(path, "error", "2"),
+				attemptMetricCount(path, "error", "3"),
+			}
+			cli = client.Extend(client.NewIAMBackendClient(closed.URL, "test", "app", "secret", client.WithRetryPolicy(policy)))
+
+			_, err := cli.GetToken()
+			assert.Error(GinkgoT(), err)
+			for i, count := range before {
+				assert.Equal(GinkgoT(), count+1, attemptMetricCount(path, "error", strconv.Itoa(i+1)))
+			}
+		})
+
+		It("no retry for the model-mutating call", func() {
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithRetryPolicy(policy)))
+
+			err := cli.AddSystem(map[string]interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int32(1), atomic.LoadInt32(&count))
+		})
+
+		It("no retry without policy", func() {
+			_, err := cli.GetToken()
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int32(1), atomic.LoadInt32(&count))
+		})
+
+		It("no retry for the status code not retryable", func() {
+			policy.RetryableStatusCodes = []int{http.StatusServiceUnavailable}
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithRetryPolicy(policy)))
+
+			_, err := cli.GetToken()
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int32(1), atomic.LoadInt32(&count))
+		})
+	})
+
+	Context("WithCircuitBreaker", func() {
+		var count int32
+		var healthy int32
+
+		BeforeEach(func() {
+			count = 0
+			healthy = 0
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&count, 1)
+				if atomic.LoadInt32(&healthy) == 0 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"token": "abc"}}`))
+			})
+
+			config := client.DefaultCircuitBreakerConfig()
+			config.FailureThreshold = 2
+			config.OpenTimeout = 50 * time.Millisecond
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithCircuitBreaker(config)))
+		})
+
+		It("open, fail fast, then half-open and close", func() {
+			for i := 0; i < 2; i++ {
+				_, err := cli.GetToken()
+				assert.Error(GinkgoT(), err)
+				assert.False(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+			}
+
+			// open
+			_, err := cli.GetToken()
+			assert.True(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&count))
+
+			// the other path is not affected
+			_, err = cli.V2PolicyQuery("test", map[string]interface{}{})
+			assert.False(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+
+			// half-open, the probe fail, open again
+			time.Sleep(60 * time.Millisecond)
+			_, err = cli.GetToken()
+			assert.False(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+			_, err = cli.GetToken()
+			assert.True(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+
+			// half-open, the probe success, closed
+			atomic.StoreInt32(&healthy, 1)
+			time.Sleep(60 * time.Millisecond)
+			token, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "abc", token)
+			_, err = cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("share the breaker by the route", func() {
+			for i := 1; i <= 2; i++ {
+				_, err := cli.PolicyGet(int64(i))
+				assert.False(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+			}
+
+			// the same route with another policy id
+			_, err := cli.PolicyGet(3)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+			assert.Contains(GinkgoT(), err.Error(), "/api/v1/systems/{system}/policies/{policy_id}")
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&count))
+		})
+
+		It("ignore the result of the call allowed before the state changed", func() {
+			slowArrived, slowRelease := make(chan struct{}), make(chan struct{})
+			probeArrived, probeRelease := make(chan struct{}), make(chan struct{})
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/100"):
+					close(slowArrived)
+					select {
+					case <-slowRelease:
+					case <-time.After(time.Second):
+					}
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {}}`))
+				case strings.HasSuffix(r.URL.Path, "/200"):
+					close(probeArrived)
+					select {
+					case <-probeRelease:
+					case <-time.After(time.Second):
+					}
+					w.WriteHeader(http.StatusServiceUnavailable)
+				default:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			})
+
+			// allowed in closed state
+			slowDone := make(chan error, 1)
+			go func() {
+				_, err := cli.PolicyGet(100)
+				slowDone <- err
+			}()
+			<-slowArrived
+
+			// open
+			for i := 1; i <= 2; i++ {
+				_, _ = cli.PolicyGet(int64(i))
+			}
+			_, err := cli.PolicyGet(3)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+
+			// half-open with the probe call inflight
+			time.Sleep(60 * time.Millisecond)
+			probeDone := make(chan error, 1)
+			go func() {
+				_, err := cli.PolicyGet(200)
+				probeDone <- err
+			}()
+			<-probeArrived
+
+			// the success of the slow call does not close the breaker or release the probe slot
+			close(slowRelease)
+			assert.NoError(GinkgoT(), <-slowDone)
+			_, err = cli.PolicyGet(3)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+
+			// the probe fail, open again
+			close(probeRelease)
+			assert.Error(GinkgoT(), <-probeDone)
+			_, err = cli.PolicyGet(3)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrCircuitOpen))
+			assert.NotContains(GinkgoT(), err.Error(), "waiting for the probe calls")
+		})
+	})
+
+	Context("WithAuthStrategy", func() {
+		var header http.Header
+
+		BeforeEach(func() {
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"token": "abc"}}`))
+			})
+		})
+
+		It("default is APIGatewayAuth", func() {
+			_, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.JSONEq(GinkgoT(), `{"bk_app_code": "app", "bk_app_secret": "secret"}`,
+				header.Get("X-Bkapi-Authorization"))
+			assert.Empty(GinkgoT(), header.Get("X-Bk-App-Code"))
+		})
+
+		It("DirectAuth", func() {
+			auth := client.DirectAuth{AppCode: "app", AppSecret: "secret"}
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithAuthStrategy(auth)))
+
+			_, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "app", header.Get("X-Bk-App-Code"))
+			assert.Equal(GinkgoT(), "secret", header.Get("X-Bk-App-Secret"))
+			assert.Empty(GinkgoT(), header.Get("X-Bkapi-Authorization"))
+		})
+
+		Context("redact the auth headers in the fail log", func() {
+			var buf *bytes.Buffer
+
+			BeforeEach(func() {
+				ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+
+				buf = &bytes.Buffer{}
+				l := logrus.New()
+				l.Out = buf
+				logger.SetLogger(l)
+			})
+
+			AfterEach(func() {
+				logger.SetLogger(logrus.New())
+			})
+
+			It("APIGatewayAuth", func() {
+				_, err := cli.GetToken()
+				assert.Error(GinkgoT(), err)
+				assert.Contains(GinkgoT(), buf.String(), "http request fail")
+				assert.NotContains(GinkgoT(), buf.String(), "secret")
+			})
+
+			It("DirectAuth", func() {
+				auth := client.DirectAuth{AppCode: "app", AppSecret: "secret"}
+				cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithAuthStrategy(auth)))
+
+				_, err := cli.GetToken()
+				assert.Error(GinkgoT(), err)
+				assert.Contains(GinkgoT(), buf.String(), "X-Bk-App-Code")
+				assert.NotContains(GinkgoT(), buf.String(), "secret")
+			})
+
+			It("custom strategy", func() {
+				auth := tokenAuth{"X-Custom-Token": "secret"}
+				cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret", client.WithAuthStrategy(auth)))
+
+				_, err := cli.GetToken()
+				assert.Error(GinkgoT(), err)
+				assert.Contains(GinkgoT(), buf.String(), "http request fail")
+				assert.NotContains(GinkgoT(), buf.String(), "secret")
+			})
+		})
+	})
+
+	Context("V2PolicyQueryDebugCtx", func() {
+		It("debug and force for this call only", func() {
+			var queries []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				queries = append(queries, r.URL.RawQuery)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"op": "any"}, "debug": {"steps": [1, 2]}}`))
+			}))
+			defer ts.Close()
+			cli := client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret"))
+
+			data, debug, err := cli.V2PolicyQueryDebugCtx(context.Background(), "test", map[string]interface{}{})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), operator.Any, data.OP)
+			assert.Equal(GinkgoT(), map[string]interface{}{"steps": []interface{}{float64(1), float64(2)}}, debug)
+
+			_, err = cli.V2PolicyQueryCtx(context.Background(), "test", map[string]interface{}{})
+			assert.NoError(GinkgoT(), err)
+
+			assert.Equal(GinkgoT(), []string{"debug=true&force=true", ""}, queries)
+		})
+	})
+
+	Context("typed responses", func() {
+		var ts *httptest.Server
+		var cli client.ExtendedClient
+
+		BeforeEach(func() {
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/api/v2/policy/systems/test/query/":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {
+						"op": "OR", "content": [
+							{"op": "eq", "field": "app.id", "value": "1"},
+							{"op": "gt", "field": "app.level", "value": 2}
+						]}}`))
+				case "/api/v2/policy/systems/test/query_by_actions/":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": [
+						{"action": {"id": "edit"}, "condition": {"op": "any", "field": "", "value": []}},
+						{"action": {"id": "view"}, "condition": {}}
+					]}`))
+				case "/api/v1/model/systems/test/query":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {
+						"base_info": {"id": "test", "name": "Test", "clients": "test"},
+						"resource_types": [{"id": "app", "name": "App", "name_en": "App"}],
+						"actions": [{"id": "edit"}, {"id": "view"}],
+						"instance_selections": []
+					}}`))
+				case "/api/v1/model/systems/test/token":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"token": "abc"}}`))
+				case "/api/v1/open/application/":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"url": "http://apply"}}`))
+				case "/api/v1/policy/auth_by_resources":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"1": true, "2": false}}`))
+				case "/api/v1/systems/test/policies":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {
+						"metadata": {"system": "test", "action": {"id": "edit"}, "timestamp": 1700000000},
+						"count": 1,
+						"results": [{"version": "1", "id": 3, "subject": {"type": "user", "id": "admin", "name": "Admin"},
+							"expression": {"op": "any", "field": "app.id", "value": []}, "expired_at": 4102444800}]
+					}}`))
+				case "/api/v1/open/authorization/instance/":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok",
+						"data": {"policy_id": 3, "statistics": {"instance_count": 1}}}`))
+				default:
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {}}`))
+				}
+			}))
+			cli = client.Extend(client.NewIAMBackendClient(ts.URL, "test", "app", "secret"))
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("V2PolicyQueryTypedCtx", func() {
+			result, err := cli.V2PolicyQueryTypedCtx(context.Background(), "test", map[string]interface{}{})
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{
+				{OP: operator.Eq, Field: "app.id", Value: "1"},
+				{OP: operator.Gt, Field: "app.level", Value: float64(2)},
+			}}, result.ExprCell)
+
+			result, err = cli.V2PolicyQueryTypedCtx(context.Background(), "empty", map[string]interface{}{})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), operator.OP(""), result.OP)
+		})
+
+		It("V2PolicyQueryByActionsTypedCtx", func() {
+			policies, err := cli.V2PolicyQueryByActionsTypedCtx(context.Background(), "test", map[string]interface{}{})
+
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), policies, 2)
+			assert.Equal(GinkgoT(), "edit", policies[0].Action.ID)
+			assert.Equal(GinkgoT(), operator.Any, policies[0].Condition.OP)
+			assert.Equal(GinkgoT(), "view", policies[1].Action.ID)
+			assert.Equal(GinkgoT(), expression.ExprCell{}, policies[1].Condition)
+		})
+
+		It("ModelQueryTypedCtx", func() {
+			model, err := cli.ModelQueryTypedCtx(context.Background(), "")
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), client.SystemModel{
+				BaseInfo:           client.ModelItem{ID: "test", Name: "Test"},
+				ResourceTypes:      []client.ModelItem{{ID: "app", Name: "App", NameEn: "App"}},
+				Actions:            []client.ModelItem{{ID: "edit"}, {ID: "view"}},
+				InstanceSelections: []client.ModelItem{},
+			}, model)
+		})
+
+		It("policy auth and list", func() {
+			result, err := cli.PolicyAuthByResourcesTypedCtx(context.Background(), map[string]interface{}{})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]bool{"1": true, "2": false}, result)
+
+			list, err := cli.PolicyListTypedCtx(context.Background(), map[string]interface{}{"action_id": "edit"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), client.PolicyListResult{
+				Metadata: client.PolicyListMetadata{Action: client.ActionPolicyAction{ID: "edit"}, Timestamp: 1700000000},
+				Count:    1,
+				Results: []client.Policy{{
+					ID:         3,
+					Version:    "1",
+					Subject:    client.PolicySubject{Type: "user", ID: "admin", Name: "Admin"},
+					Expression: expression.ExprCell{OP: operator.Any, Field: "app.id", Value: []interface{}{}},
+					ExpiredAt:  4102444800,
+				}},
+			}, list)
+
+			policy, err := cli.GrantOrRevokeInstancePermissionCtx(context.Background(), map[string]interface{}{})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(3), policy.PolicyID)
+		})
+
+		It("token and apply url", func() {
+			token, err := cli.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "abc", token)
+
+			url, err := cli.GetApplyURL(map[string]interface{}{})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "http://apply", url)
+		})
+	})
+})
@@ -0,0 +1,216 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/iam-go-sdk/logger"
+	"github.com/TencentBlueKing/iam-go-sdk/metric"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker of the route is open, the call fails fast without http request
+var ErrCircuitOpen = errors.New("iam backend circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed the calls are allowed
+	CircuitClosed CircuitState = iota
+	// CircuitOpen the calls fail fast with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen a limited number of probe calls are allowed to check if the backend recovered
+	CircuitHalfOpen
+)
+
+// String return the text of circuit state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig is the config of the circuit breaker, each route(the fixed path template, e.g.
+// `/api/v1/systems/{system}/policies/{policy_id}`) of iam backend has its own breaker
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures to open the breaker
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before half-open
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the max number of concurrent probe calls in half-open state
+	HalfOpenMaxRequests int
+	// IsFailure checks if the call is failed by the status code(0 means no response) and the error,
+	// default is network errors(except canceled) and 5xx status codes
+	IsFailure func(statusCode int, err error) bool
+}
+
+// DefaultCircuitBreakerConfig returns the recommended circuit breaker config:
+// open after 5 consecutive failures, half-open after 10s with 1 probe call
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold:    5,
+		OpenTimeout:         10 * time.Second,
+		HalfOpenMaxRequests: 1,
+		IsFailure:           isCircuitFailure,
+	}
+}
+
+// WithCircuitBreaker enable the circuit breaker of the iam backend calls
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(c *iamBackendClient) {
+		c.breaker = newCircuitBreaker(config)
+	}
+}
+
+func isCircuitFailure(statusCode int, err error) bool {
+	if statusCode == 0 {
+		return err != nil && !errors.Is(err, context.Canceled)
+	}
+	return statusCode >= http.StatusInternalServerError
+}
+
+type routeBreaker struct {
+	state            CircuitState
+	failures         int
+	openedAt         time.Time
+	halfOpenInflight int
+	// generation is increased on every state change, the results of the calls allowed in older generations
+	// (e.g. allowed in closed state but done in half-open state) are ignored
+	generation uint64
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu     sync.Mutex
+	routes map[string]*routeBreaker
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 1
+	}
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = isCircuitFailure
+	}
+
+	return &circuitBreaker{
+		config: config,
+		routes: make(map[string]*routeBreaker),
+	}
+}
+
+// allow checks if the call of the route is allowed, return ErrCircuitOpen if not,
+// the returned generation should be passed to done
+func (b *circuitBreaker) allow(route string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rb, ok := b.routes[route]
+	if !ok {
+		rb = &routeBreaker{}
+		b.routes[route] = rb
+	}
+
+	switch rb.state {
+	case CircuitOpen:
+		if time.Since(rb.openedAt) < b.config.OpenTimeout {
+			return 0, fmt.Errorf("%w: route=`%s`", ErrCircuitOpen, route)
+		}
+		b.setState(route, rb, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if rb.halfOpenInflight >= b.config.HalfOpenMaxRequests {
+			return 0, fmt.Errorf("%w: route=`%s`, waiting for the probe calls", ErrCircuitOpen, route)
+		}
+		rb.halfOpenInflight++
+	}
+	return rb.generation, nil
+}
+
+// done records the result of the allowed call of the route, ignore it if the state changed after allowed
+func (b *circuitBreaker) done(route string, generation uint64, statusCode int, err error) {
+	failed := b.config.IsFailure(statusCode, err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rb := b.routes[route]
+	if rb.generation != generation {
+		return
+	}
+
+	switch rb.state {
+	case CircuitHalfOpen:
+		rb.halfOpenInflight--
+		if failed {
+			rb.openedAt = time.Now()
+			b.setState(route, rb, CircuitOpen)
+		} else {
+			rb.failures = 0
+			b.setState(route, rb, CircuitClosed)
+		}
+	case CircuitClosed:
+		if !failed {
+			rb.failures = 0
+			return
+		}
+		rb.failures++
+		if rb.failures >= b.config.FailureThreshold {
+			rb.openedAt = time.Now()
+			b.setState(route, rb, CircuitOpen)
+		}
+	}
+}
+
+func (b *circuitBreaker) setState(route string, rb *routeBreaker, state CircuitState) {
+	if rb.state == state {
+		return
+	}
+
+	logger.Warnf("iam backend circuit breaker state changed from %s to %s [route=%s, failures=%d]",
+		rb.state, state, route, rb.failures)
+
+	rb.state = state
+	rb.generation++
+	if state != CircuitHalfOpen {
+		rb.halfOpenInflight = 0
+	}
+
+	metric.ClientCircuitBreakerState.With(prometheus.Labels{
+		"route":     route,
+		"component": metricComponent,
+	}).Set(float64(state))
+	metric.ClientCircuitBreakerStateChanges.With(prometheus.Labels{
+		"route":     route,
+		"state":     state.String(),
+		"component": metricComponent,
+	}).Inc()
+}
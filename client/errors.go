@@ -0,0 +1,115 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// the sentinel errors of iam backend, use errors.Is(err, client.ErrNotFound) to check
+var (
+	// ErrBadRequest the request is invalid
+	ErrBadRequest = errors.New("iam backend bad request")
+	// ErrUnauthorized the app_code/app_secret is invalid, or the app is not authorized
+	ErrUnauthorized = errors.New("iam backend unauthorized")
+	// ErrForbidden the app has no permission to call the api
+	ErrForbidden = errors.New("iam backend forbidden")
+	// ErrNotFound the system or model not found
+	ErrNotFound = errors.New("iam backend not found")
+	// ErrConflict the model already exists
+	ErrConflict = errors.New("iam backend conflict")
+	// ErrTooManyRequests the call is rate limited
+	ErrTooManyRequests = errors.New("iam backend too many requests")
+	// ErrServerError the iam backend or APIGateway is failed
+	ErrServerError = errors.New("iam backend server error")
+)
+
+// ErrNotSupported the call is not supported by the IAMBackendClient, see Extend
+var ErrNotSupported = errors.New("iam backend client not supported")
+
+const (
+	maxErrorMessageLength = 1024
+
+	// the iam backend error codes are 1901xxx, the last 3 digits are the http status code
+	iamCodePrefix    = 1901000
+	iamCodeMaxSuffix = 999
+)
+
+// APIError is the error returned by iam backend, including the non-200 http response and the non-zero body.code
+type APIError struct {
+	// StatusCode is the http status code
+	StatusCode int
+	// Code is the body.code of iam backend response, 0 if the response body is not valid
+	Code int
+	// Message is the body.message of iam backend response, or the response body if not valid
+	Message string
+	// RequestID is the request id from response header X-Request-Id
+	RequestID string
+	Method    Method
+	Path      string
+}
+
+func newAPIError(method Method, path string, resp *http.Response, code int, message string) *APIError {
+	requestID := resp.Header.Get("X-Request-Id")
+	if requestID == "" {
+		requestID = resp.Header.Get("X-Bkapi-Request-Id")
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       code,
+		Message:    message,
+		RequestID:  requestID,
+		Method:     method,
+		Path:       path,
+	}
+}
+
+// Error return the text of the error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("iam backend api error[status=`%d`, code=`%d`, message=`%s`, request_id=`%s`, api=`%s %s`]",
+		e.StatusCode, e.Code, e.Message, e.RequestID, e.Method, e.Path)
+}
+
+// Is make the APIError works with errors.Is and the sentinel errors
+func (e *APIError) Is(target error) bool {
+	status := e.status()
+
+	switch target {
+	case ErrBadRequest:
+		return status == http.StatusBadRequest
+	case ErrUnauthorized:
+		return status == http.StatusUnauthorized
+	case ErrForbidden:
+		return status == http.StatusForbidden
+	case ErrNotFound:
+		return status == http.StatusNotFound
+	case ErrConflict:
+		return status == http.StatusConflict
+	case ErrTooManyRequests:
+		return status == http.StatusTooManyRequests
+	case ErrServerError:
+		return status >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// status returns the http status of the error, the body.code takes precedence over the http status code,
+// because iam backend may response 200 with the error code 1901xxx
+func (e *APIError) status() int {
+	if e.Code > iamCodePrefix && e.Code <= iamCodePrefix+iamCodeMaxSuffix {
+		return e.Code - iamCodePrefix
+	}
+	return e.StatusCode
+}
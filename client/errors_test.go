@@ -0,0 +1,77 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+)
+
+var _ = Describe("Errors", func() {
+
+	DescribeTable("APIError.Is", func(err *client.APIError, target error, expected bool) {
+		assert.Equal(GinkgoT(), expected, errors.Is(err, target))
+	},
+		Entry("status 404", &client.APIError{StatusCode: 404}, client.ErrNotFound, true),
+		Entry("status 401", &client.APIError{StatusCode: 401}, client.ErrUnauthorized, true),
+		Entry("status 502", &client.APIError{StatusCode: 502}, client.ErrServerError, true),
+		Entry("status 429", &client.APIError{StatusCode: 429}, client.ErrTooManyRequests, true),
+		Entry("code 1901404", &client.APIError{StatusCode: 200, Code: 1901404}, client.ErrNotFound, true),
+		Entry("code 1901409", &client.APIError{StatusCode: 200, Code: 1901409}, client.ErrConflict, true),
+		Entry("code 1901400", &client.APIError{StatusCode: 200, Code: 1901400}, client.ErrBadRequest, true),
+		Entry("code over status", &client.APIError{StatusCode: 400, Code: 1901404}, client.ErrBadRequest, false),
+		Entry("unknown code", &client.APIError{StatusCode: 200, Code: 1}, client.ErrNotFound, false),
+	)
+
+	It("from backend response", func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-Id", "abc123")
+			_, _ = w.Write([]byte(`{"code": 1901404, "message": "system not found", "data": {}}`))
+		}))
+		defer ts.Close()
+
+		cli := client.NewIAMBackendClient(ts.URL, "test", "app", "secret")
+		_, err := cli.ModelQuery("test")
+		assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+
+		var apiErr *client.APIError
+		assert.True(GinkgoT(), errors.As(err, &apiErr))
+		assert.Equal(GinkgoT(), http.StatusOK, apiErr.StatusCode)
+		assert.Equal(GinkgoT(), 1901404, apiErr.Code)
+		assert.Equal(GinkgoT(), "system not found", apiErr.Message)
+		assert.Equal(GinkgoT(), "abc123", apiErr.RequestID)
+		assert.Equal(GinkgoT(), "/api/v1/model/systems/test/query", apiErr.Path)
+	})
+
+	It("from non-json response", func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`unauthorized app`))
+		}))
+		defer ts.Close()
+
+		cli := client.NewIAMBackendClient(ts.URL, "test", "app", "secret")
+		_, err := cli.GetToken()
+		assert.True(GinkgoT(), errors.Is(err, client.ErrUnauthorized))
+
+		var apiErr *client.APIError
+		assert.True(GinkgoT(), errors.As(err, &apiErr))
+		assert.Equal(GinkgoT(), "unauthorized app", apiErr.Message)
+	})
+})
@@ -0,0 +1,462 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Extend will return the ExtendedClient of the IAMBackendClient, the method groups implemented by the client
+// are detected by type assertion, the others fallback to the methods of IAMBackendClient:
+//   - ContextClient: check the ctx before the call, the ctx is not passed to the call
+//   - TypedClient: decode the map data into the typed response
+//   - DebugClient: the debug info is empty
+//   - AuthorizationClient: return ErrNotSupported
+func Extend(cli IAMBackendClient) ExtendedClient {
+	if c, ok := cli.(ExtendedClient); ok {
+		return c
+	}
+	return &extendedClient{IAMBackendClient: cli}
+}
+
+type extendedClient struct {
+	IAMBackendClient
+}
+
+// GetTokenCtx will get the token of system with the ctx
+func (c *extendedClient) GetTokenCtx(ctx context.Context) (token string, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.GetTokenCtx(ctx)
+	}
+	if err = ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.GetToken()
+}
+
+// V2PolicyQueryCtx will do policy query with the ctx
+func (c *extendedClient) V2PolicyQueryCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.V2PolicyQueryCtx(ctx, system, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.V2PolicyQuery(system, body)
+}
+
+// V2PolicyQueryByActionsCtx will do policy query by actions with the ctx
+func (c *extendedClient) V2PolicyQueryByActionsCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data []map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.V2PolicyQueryByActionsCtx(ctx, system, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.V2PolicyQueryByActions(system, body)
+}
+
+// V2PolicyAuthCtx will do policy auth with the ctx
+func (c *extendedClient) V2PolicyAuthCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.V2PolicyAuthCtx(ctx, system, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.V2PolicyAuth(system, body)
+}
+
+// PolicyAuthCtx will do policy auth with the ctx
+func (c *extendedClient) PolicyAuthCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.PolicyAuthCtx(ctx, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.PolicyAuth(body)
+}
+
+// PolicyAuthByResourcesCtx will do policy auth by resources with the ctx
+func (c *extendedClient) PolicyAuthByResourcesCtx(
+	ctx context.Context,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.PolicyAuthByResourcesCtx(ctx, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.PolicyAuthByResources(body)
+}
+
+// PolicyAuthByActionsCtx will do policy auth by actions with the ctx
+func (c *extendedClient) PolicyAuthByActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.PolicyAuthByActionsCtx(ctx, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.PolicyAuthByActions(body)
+}
+
+// PolicyGetCtx will get the policy detail by id with the ctx
+func (c *extendedClient) PolicyGetCtx(ctx context.Context, policyID int64) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.PolicyGetCtx(ctx, policyID)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.PolicyGet(policyID)
+}
+
+// PolicyListCtx will list all the policy with the ctx
+func (c *extendedClient) PolicyListCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.PolicyListCtx(ctx, body)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.PolicyList(body)
+}
+
+// PolicySubjectsCtx will query the subject of each policy with the ctx
+func (c *extendedClient) PolicySubjectsCtx(
+	ctx context.Context,
+	policyIDs []int64,
+) (data []map[string]interface{}, err error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.PolicySubjectsCtx(ctx, policyIDs)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.PolicySubjects(policyIDs)
+}
+
+// GetApplyURLCtx will get apply url with the ctx
+func (c *extendedClient) GetApplyURLCtx(ctx context.Context, body interface{}) (string, error) {
+	if cc, ok := c.IAMBackendClient.(ContextClient); ok {
+		return cc.GetApplyURLCtx(ctx, body)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.GetApplyURL(body)
+}
+
+// V2PolicyQueryTypedCtx will do policy query with the ctx, the data is decoded into PolicyQueryResult
+func (c *extendedClient) V2PolicyQueryTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyQueryResult, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.V2PolicyQueryTypedCtx(ctx, system, body)
+	}
+	data, err := c.V2PolicyQueryCtx(ctx, system, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &result)
+	return
+}
+
+// V2PolicyQueryByActionsTypedCtx will do policy query by actions with the ctx, the data is decoded into []ActionPolicy
+func (c *extendedClient) V2PolicyQueryByActionsTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (policies []ActionPolicy, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.V2PolicyQueryByActionsTypedCtx(ctx, system, body)
+	}
+	data, err := c.V2PolicyQueryByActionsCtx(ctx, system, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &policies)
+	return
+}
+
+// V2PolicyAuthTypedCtx will do policy auth with the ctx, the data is decoded into PolicyAuthResult
+func (c *extendedClient) V2PolicyAuthTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyAuthResult, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.V2PolicyAuthTypedCtx(ctx, system, body)
+	}
+	data, err := c.V2PolicyAuthCtx(ctx, system, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &result)
+	return
+}
+
+// PolicyAuthTypedCtx will do policy auth with the ctx, the data is decoded into PolicyAuthResult
+func (c *extendedClient) PolicyAuthTypedCtx(ctx context.Context, body interface{}) (result PolicyAuthResult, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.PolicyAuthTypedCtx(ctx, body)
+	}
+	data, err := c.PolicyAuthCtx(ctx, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &result)
+	return
+}
+
+// PolicyAuthByResourcesTypedCtx will do policy auth by resources with the ctx,
+// the data is decoded into the allowed of each resource id
+func (c *extendedClient) PolicyAuthByResourcesTypedCtx(
+	ctx context.Context,
+	body interface{},
+) (result map[string]bool, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.PolicyAuthByResourcesTypedCtx(ctx, body)
+	}
+	data, err := c.PolicyAuthByResourcesCtx(ctx, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &result)
+	return
+}
+
+// PolicyAuthByActionsTypedCtx will do policy auth by actions with the ctx,
+// the data is decoded into the allowed of each action id
+func (c *extendedClient) PolicyAuthByActionsTypedCtx(
+	ctx context.Context,
+	body interface{},
+) (result map[string]bool, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.PolicyAuthByActionsTypedCtx(ctx, body)
+	}
+	data, err := c.PolicyAuthByActionsCtx(ctx, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &result)
+	return
+}
+
+// PolicyGetTypedCtx will get the policy detail by id with the ctx, the data is decoded into Policy
+func (c *extendedClient) PolicyGetTypedCtx(ctx context.Context, policyID int64) (policy Policy, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.PolicyGetTypedCtx(ctx, policyID)
+	}
+	data, err := c.PolicyGetCtx(ctx, policyID)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &policy)
+	return
+}
+
+// PolicyListTypedCtx will list the policies with the ctx, the data is decoded into PolicyListResult
+func (c *extendedClient) PolicyListTypedCtx(ctx context.Context, body interface{}) (result PolicyListResult, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.PolicyListTypedCtx(ctx, body)
+	}
+	data, err := c.PolicyListCtx(ctx, body)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &result)
+	return
+}
+
+// PolicySubjectsTypedCtx will query the subject of each policy with the ctx, the data is decoded into []PolicySubjectItem
+func (c *extendedClient) PolicySubjectsTypedCtx(
+	ctx context.Context,
+	policyIDs []int64,
+) (items []PolicySubjectItem, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.PolicySubjectsTypedCtx(ctx, policyIDs)
+	}
+	data, err := c.PolicySubjectsCtx(ctx, policyIDs)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &items)
+	return
+}
+
+// ModelQueryTypedCtx will query the model of the system with the ctx, the data is decoded into SystemModel
+func (c *extendedClient) ModelQueryTypedCtx(ctx context.Context, system string) (model SystemModel, err error) {
+	if tc, ok := c.IAMBackendClient.(TypedClient); ok {
+		return tc.ModelQueryTypedCtx(ctx, system)
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	data, err := c.ModelQuery(system)
+	if err != nil {
+		return
+	}
+	err = decodeTyped(data, &model)
+	return
+}
+
+// V2PolicyQueryDebugCtx will do policy query with the ctx, the debug info is empty if not supported
+func (c *extendedClient) V2PolicyQueryDebugCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyQueryResult, debug map[string]interface{}, err error) {
+	if dc, ok := c.IAMBackendClient.(DebugClient); ok {
+		return dc.V2PolicyQueryDebugCtx(ctx, system, body)
+	}
+	result, err = c.V2PolicyQueryTypedCtx(ctx, system, body)
+	return result, map[string]interface{}{}, err
+}
+
+// GrantResourceCreatorActions will grant the resource creator the actions
+func (c *extendedClient) GrantResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.GrantResourceCreatorActionsCtx(context.Background(), body)
+}
+
+// GrantResourceCreatorActionsCtx will grant the resource creator the actions with the ctx
+func (c *extendedClient) GrantResourceCreatorActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if ac, ok := c.IAMBackendClient.(AuthorizationClient); ok {
+		return ac.GrantResourceCreatorActionsCtx(ctx, body)
+	}
+	return nil, errNotSupported("GrantResourceCreatorActions")
+}
+
+// GrantBatchResourceCreatorActions will grant the creator of batch resources the actions
+func (c *extendedClient) GrantBatchResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.GrantBatchResourceCreatorActionsCtx(context.Background(), body)
+}
+
+// GrantBatchResourceCreatorActionsCtx will grant the creator of batch resources the actions with the ctx
+func (c *extendedClient) GrantBatchResourceCreatorActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if ac, ok := c.IAMBackendClient.(AuthorizationClient); ok {
+		return ac.GrantBatchResourceCreatorActionsCtx(ctx, body)
+	}
+	return nil, errNotSupported("GrantBatchResourceCreatorActions")
+}
+
+// GrantOrRevokeInstancePermission will grant or revoke the permission of the resource instances
+func (c *extendedClient) GrantOrRevokeInstancePermission(body interface{}) (policy AuthorizationPolicy, err error) {
+	return c.GrantOrRevokeInstancePermissionCtx(context.Background(), body)
+}
+
+// GrantOrRevokeInstancePermissionCtx will grant or revoke the permission of the resource instances with the ctx
+func (c *extendedClient) GrantOrRevokeInstancePermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policy AuthorizationPolicy, err error) {
+	if ac, ok := c.IAMBackendClient.(AuthorizationClient); ok {
+		return ac.GrantOrRevokeInstancePermissionCtx(ctx, body)
+	}
+	return policy, errNotSupported("GrantOrRevokeInstancePermission")
+}
+
+// BatchGrantOrRevokeInstancePermission will grant or revoke the permissions of the resource instances in batch
+func (c *extendedClient) BatchGrantOrRevokeInstancePermission(
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	return c.BatchGrantOrRevokeInstancePermissionCtx(context.Background(), body)
+}
+
+// BatchGrantOrRevokeInstancePermissionCtx will grant or revoke the permissions in batch with the ctx
+func (c *extendedClient) BatchGrantOrRevokeInstancePermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if ac, ok := c.IAMBackendClient.(AuthorizationClient); ok {
+		return ac.BatchGrantOrRevokeInstancePermissionCtx(ctx, body)
+	}
+	return nil, errNotSupported("BatchGrantOrRevokeInstancePermission")
+}
+
+// GrantOrRevokePathPermission will grant or revoke the permission of the topology paths
+func (c *extendedClient) GrantOrRevokePathPermission(body interface{}) (policy AuthorizationPolicy, err error) {
+	return c.GrantOrRevokePathPermissionCtx(context.Background(), body)
+}
+
+// GrantOrRevokePathPermissionCtx will grant or revoke the permission of the topology paths with the ctx
+func (c *extendedClient) GrantOrRevokePathPermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policy AuthorizationPolicy, err error) {
+	if ac, ok := c.IAMBackendClient.(AuthorizationClient); ok {
+		return ac.GrantOrRevokePathPermissionCtx(ctx, body)
+	}
+	return policy, errNotSupported("GrantOrRevokePathPermission")
+}
+
+// BatchGrantOrRevokePathPermission will grant or revoke the permissions of the topology paths in batch
+func (c *extendedClient) BatchGrantOrRevokePathPermission(
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	return c.BatchGrantOrRevokePathPermissionCtx(context.Background(), body)
+}
+
+// BatchGrantOrRevokePathPermissionCtx will grant or revoke the permissions of the topology paths in batch with the ctx
+func (c *extendedClient) BatchGrantOrRevokePathPermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if ac, ok := c.IAMBackendClient.(AuthorizationClient); ok {
+		return ac.BatchGrantOrRevokePathPermissionCtx(ctx, body)
+	}
+	return nil, errNotSupported("BatchGrantOrRevokePathPermission")
+}
+
+// decodeTyped will decode the map data of IAMBackendClient into the typed response by json
+func decodeTyped(data interface{}, v interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("decode the data fail: %w", err)
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decode the data fail: %w", err)
+	}
+	return nil
+}
+
+func errNotSupported(method string) error {
+	return fmt.Errorf("%s: %w", method, ErrNotSupported)
+}
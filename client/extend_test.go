@@ -0,0 +1,87 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// baseClient only implements the methods of IAMBackendClient, as the implementations outside the sdk
+type baseClient struct {
+	client.IAMBackendClient
+}
+
+var _ = Describe("Extend", func() {
+	body := map[string]interface{}{
+		"system":  "demo",
+		"subject": map[string]interface{}{"type": "user", "id": "admin"},
+		"action":  map[string]interface{}{"id": "edit"},
+		"actions": []map[string]interface{}{{"id": "edit"}},
+	}
+
+	It("the ExtendedClient is returned as is", func() {
+		cli := client.NewMemoryClient()
+		assert.Equal(GinkgoT(), client.ExtendedClient(cli), client.Extend(cli))
+	})
+
+	It("fallback to IAMBackendClient", func() {
+		mem := client.NewMemoryClient()
+		mem.Grant("demo", "user", "admin", "edit", expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "1"})
+		mem.RegisterModel("demo", []string{"app"}, nil, []string{"edit"})
+
+		cli := client.Extend(baseClient{mem})
+		_, ok := cli.(*client.MemoryClient)
+		assert.False(GinkgoT(), ok)
+
+		// typed
+		result, err := cli.V2PolicyQueryTypedCtx(context.Background(), "demo", body)
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), operator.Eq, result.OP)
+		assert.Equal(GinkgoT(), "app.id", result.Field)
+		assert.Equal(GinkgoT(), "1", result.Value)
+
+		policies, err := cli.V2PolicyQueryByActionsTypedCtx(context.Background(), "demo", body)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), policies, 1)
+		assert.Equal(GinkgoT(), "edit", policies[0].Action.ID)
+		assert.Equal(GinkgoT(), operator.Eq, policies[0].Condition.OP)
+
+		model, err := cli.ModelQueryTypedCtx(context.Background(), "demo")
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), "demo", model.BaseInfo.ID)
+		assert.Equal(GinkgoT(), []client.ModelItem{{ID: "edit"}}, model.Actions)
+
+		// debug
+		_, debug, err := cli.V2PolicyQueryDebugCtx(context.Background(), "demo", body)
+		assert.NoError(GinkgoT(), err)
+		assert.Empty(GinkgoT(), debug)
+
+		// ctx
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = cli.V2PolicyQueryCtx(ctx, "demo", body)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+		_, err = cli.V2PolicyQueryTypedCtx(ctx, "demo", body)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+
+		// authorization
+		_, err = cli.GrantResourceCreatorActions(body)
+		assert.ErrorIs(GinkgoT(), err, client.ErrNotSupported)
+	})
+})
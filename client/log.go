@@ -26,16 +26,24 @@ const (
 	maxResponseBodyLength = 10240
 )
 
+// defaultSensitiveHeaders are always removed from the curl command, including the headers of the built-in auth strategies
+var defaultSensitiveHeaders = []string{"Authorization", "X-Bkapi-Authorization", "X-Bk-App-Secret"}
+
 // AsCurlCommand returns a string representing the runnable `curl' command
-// version of the request.
-func AsCurlCommand(request *gorequest.SuperAgent) (string, error) {
+// version of the request, the default sensitive headers and the given sensitiveHeaders are removed.
+func AsCurlCommand(request *gorequest.SuperAgent, sensitiveHeaders ...string) (string, error) {
 	req, err := request.MakeRequest()
 	if err != nil {
 		return "", err
 	}
 
-	// 脱敏, 去掉-H 中 Authorization
-	req.Header.Del("Authorization")
+	// 脱敏, 去掉-H 中的认证信息
+	for _, header := range defaultSensitiveHeaders {
+		req.Header.Del(header)
+	}
+	for _, header := range sensitiveHeaders {
+		req.Header.Del(header)
+	}
 
 	cmd, err := http2curl.GetCurlCommand(req)
 	if err != nil {
@@ -50,9 +58,10 @@ func logFailHTTPRequest(
 	respBody []byte,
 	errs []error,
 	data responseBody,
+	sensitiveHeaders []string,
 ) {
 
-	dump, err := AsCurlCommand(request)
+	dump, err := AsCurlCommand(request, sensitiveHeaders...)
 	if err != nil {
 		logger.Errorf("component request AsCurlCommand fail, error: %v", err)
 	}
@@ -0,0 +1,1607 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ ExtendedClient = &MemoryClient{}
+
+const (
+	// MemoryToken is the default system token of the MemoryClient
+	MemoryToken = "memory-token"
+	// MemoryApplyURL is the default apply url of the MemoryClient
+	MemoryApplyURL = "http://bk-iam.memory/apply/"
+	// MemoryPolicyExpiredAt is the expired_at of the granted policies, same as the never expired policies of iam
+	MemoryPolicyExpiredAt int64 = 4102444800
+)
+
+// the default page size of PolicyList
+const memoryDefaultPageSize = 100
+
+// the model types in the model query response
+const (
+	modelKeyResourceTypes      = "resource_types"
+	modelKeyInstanceSelections = "instance_selections"
+	modelKeyActions            = "actions"
+)
+
+// ModelMutation is the model mutation recorded by the MemoryClient, e.g. the calls made by iammigrate
+type ModelMutation struct {
+	// Operation is the method name of the mutation, e.g. AddResourceType
+	Operation string
+	System    string
+	// IDs is the model ids of the mutation, e.g. the resource type ids
+	IDs  []string
+	Body interface{}
+}
+
+type memoryPolicy struct {
+	id          int64
+	system      string
+	subjectType string
+	subjectID   string
+	actionID    string
+	expr        expression.ExprCell
+	expiredAt   int64
+}
+
+// memoryPolicyRequest is the policy query request, decoded from iam.Request/iam.MultiActionRequest or the map
+type memoryPolicyRequest struct {
+	System  string
+	Subject struct {
+		Type string
+		ID   string
+	}
+	Action struct {
+		ID string
+	}
+	Actions []struct {
+		ID string
+	}
+	Resources     []memoryResource
+	ResourcesList [][]memoryResource `mapstructure:"resources_list"`
+}
+
+// memoryResource is the resource node of the policy auth request
+type memoryResource struct {
+	System    string
+	Type      string
+	ID        string
+	Attribute map[string]interface{}
+}
+
+// memoryPolicyListRequest is the policy list request, the query of PolicyList
+type memoryPolicyListRequest struct {
+	ActionID  string `mapstructure:"action_id"`
+	Page      int64  `mapstructure:"page"`
+	PageSize  int64  `mapstructure:"page_size"`
+	Timestamp int64  `mapstructure:"timestamp"`
+}
+
+// memoryCreatorRequest is the grant resource creator actions request, decoded from the iam requests by json
+type memoryCreatorRequest struct {
+	System    string `json:"system"`
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Creator   string `json:"creator"`
+	Instances []struct {
+		ID string `json:"id"`
+	} `json:"instances"`
+}
+
+// memoryCreatorConfig is the resource_creator_actions config of the resource type
+type memoryCreatorConfig struct {
+	ID      string `json:"id"`
+	Actions []struct {
+		ID string `json:"id"`
+	} `json:"actions"`
+	SubResourceTypes []memoryCreatorConfig `json:"sub_resource_types"`
+}
+
+// memoryPermissionRequest is the grant or revoke permission request, decoded from the iam requests by json
+type memoryPermissionRequest struct {
+	Operate string `json:"operate"`
+	System  string `json:"system"`
+	Subject struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"subject"`
+	Action struct {
+		ID string `json:"id"`
+	} `json:"action"`
+	Actions []struct {
+		ID string `json:"id"`
+	} `json:"actions"`
+	Resources []memoryPermissionResource `json:"resources"`
+	ExpiredAt int64                      `json:"expired_at"`
+}
+
+// memoryPermissionResource is the resource of the grant or revoke permission request,
+// one of the instance(ID), the instances, the path or the paths is set
+type memoryPermissionResource struct {
+	System    string                 `json:"system"`
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id"`
+	Instances [][]memoryResourceNode `json:"instances"`
+	Path      []memoryResourceNode   `json:"path"`
+	Paths     [][]memoryResourceNode `json:"paths"`
+}
+
+// memoryResourceNode is the resource node of the instance or the path
+type memoryResourceNode struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// MemoryClient is an in-memory IAMBackendClient for unit tests, all the calls are handled without network,
+// the policies are granted by Grant or the authorization apis, and the models are registered by RegisterModel
+// or the model apis
+type MemoryClient struct {
+	mu        sync.Mutex
+	system    string
+	token     string
+	applyURL  string
+	policies  []memoryPolicy
+	policyID  int64
+	models    map[string]map[string]interface{}
+	mutations []ModelMutation
+}
+
+// NewMemoryClient will create an in-memory iam backend client
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{
+		token:    MemoryToken,
+		applyURL: MemoryApplyURL,
+		models:   map[string]map[string]interface{}{},
+	}
+}
+
+// SetToken set the system token
+func (c *MemoryClient) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// SetSystem set the system of the client, as the system of the iam backend client,
+// PolicyGet/PolicyList/PolicySubjects only return the policies of the system
+func (c *MemoryClient) SetSystem(system string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.system = system
+}
+
+// SetApplyURL set the url returned by GetApplyURL
+func (c *MemoryClient) SetApplyURL(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.applyURL = url
+}
+
+// Grant grants the subject the action with the expression, return the id of the policy;
+// multiple grants of the same subject and action will be combined with OR
+func (c *MemoryClient) Grant(system, subjectType, subjectID, actionID string, expr expression.ExprCell) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policyID++
+	c.policies = append(c.policies, memoryPolicy{
+		id:          c.policyID,
+		system:      system,
+		subjectType: subjectType,
+		subjectID:   subjectID,
+		actionID:    actionID,
+		expr:        expr,
+		expiredAt:   MemoryPolicyExpiredAt,
+	})
+	return c.policyID
+}
+
+// GrantAny grants the subject the action without any condition, return the id of the policy
+func (c *MemoryClient) GrantAny(system, subjectType, subjectID, actionID string) int64 {
+	return c.Grant(system, subjectType, subjectID, actionID, expression.ExprCell{OP: operator.Any, Value: []interface{}{}})
+}
+
+// RevokeAll removes all the granted policies
+func (c *MemoryClient) RevokeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policies = nil
+}
+
+// RegisterModel registers the model of the system, the ids of the resource types/instance selections/actions
+// will be returned by ModelQuery, so the iammigrate upsert operations will do update
+func (c *MemoryClient) RegisterModel(system string, resourceTypeIDs, instanceSelectionIDs, actionIDs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model := c.ensureModel(system)
+	model["base_info"] = map[string]interface{}{"id": system}
+	model[modelKeyResourceTypes] = idItems(resourceTypeIDs)
+	model[modelKeyInstanceSelections] = idItems(instanceSelectionIDs)
+	model[modelKeyActions] = idItems(actionIDs)
+}
+
+// Mutations returns the recorded model mutations in order
+func (c *MemoryClient) Mutations() []ModelMutation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ModelMutation(nil), c.mutations...)
+}
+
+// Ping will always success
+func (c *MemoryClient) Ping() error {
+	return nil
+}
+
+// GetToken will get the token of system
+func (c *MemoryClient) GetToken() (token string, err error) {
+	return c.GetTokenCtx(context.Background())
+}
+
+// GetTokenCtx will get the token of system
+func (c *MemoryClient) GetTokenCtx(ctx context.Context) (token string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token, nil
+}
+
+// PolicyQuery will do policy query
+func (c *MemoryClient) PolicyQuery(body interface{}) (map[string]interface{}, error) {
+	return c.V2PolicyQueryCtx(context.Background(), "", body)
+}
+
+// PolicyQueryByActions will do policy query by actions
+func (c *MemoryClient) PolicyQueryByActions(body interface{}) ([]map[string]interface{}, error) {
+	return c.V2PolicyQueryByActionsCtx(context.Background(), "", body)
+}
+
+// V2PolicyQuery will do policy query
+func (c *MemoryClient) V2PolicyQuery(system string, body interface{}) (data map[string]interface{}, err error) {
+	return c.V2PolicyQueryCtx(context.Background(), system, body)
+}
+
+// V2PolicyQueryCtx will do policy query, return the expression of the granted policies
+func (c *MemoryClient) V2PolicyQueryCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return nil, err
+	}
+	if system == "" {
+		system = req.System
+	}
+
+	expr := c.queryExpr(system, req.Subject.Type, req.Subject.ID, req.Action.ID)
+	if expr == nil {
+		return map[string]interface{}{}, nil
+	}
+	return exprToMap(*expr), nil
+}
+
+// V2PolicyQueryTypedCtx will do policy query, return the expression of the granted policies
+func (c *MemoryClient) V2PolicyQueryTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyQueryResult, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return
+	}
+	if system == "" {
+		system = req.System
+	}
+
+	if expr := c.queryExpr(system, req.Subject.Type, req.Subject.ID, req.Action.ID); expr != nil {
+		result.ExprCell = *expr
+	}
+	return result, nil
+}
+
+// V2PolicyQueryDebugCtx will do policy query, the debug info is always empty
+func (c *MemoryClient) V2PolicyQueryDebugCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyQueryResult, debug map[string]interface{}, err error) {
+	result, err = c.V2PolicyQueryTypedCtx(ctx, system, body)
+	return result, map[string]interface{}{}, err
+}
+
+// V2PolicyQueryByActions will do policy query by actions
+func (c *MemoryClient) V2PolicyQueryByActions(system string, body interface{}) (data []map[string]interface{}, err error) {
+	return c.V2PolicyQueryByActionsCtx(context.Background(), system, body)
+}
+
+// V2PolicyQueryByActionsCtx will do policy query by actions, return the expression of each action
+func (c *MemoryClient) V2PolicyQueryByActionsCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data []map[string]interface{}, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return nil, err
+	}
+	if system == "" {
+		system = req.System
+	}
+
+	data = make([]map[string]interface{}, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		condition := map[string]interface{}{}
+		if expr := c.queryExpr(system, req.Subject.Type, req.Subject.ID, action.ID); expr != nil {
+			condition = exprToMap(*expr)
+		}
+		data = append(data, map[string]interface{}{
+			"action":    map[string]interface{}{"id": action.ID},
+			"condition": condition,
+		})
+	}
+	return data, nil
+}
+
+// V2PolicyQueryByActionsTypedCtx will do policy query by actions, return the expression of each action
+func (c *MemoryClient) V2PolicyQueryByActionsTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (policies []ActionPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return nil, err
+	}
+	if system == "" {
+		system = req.System
+	}
+
+	policies = make([]ActionPolicy, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		policy := ActionPolicy{Action: ActionPolicyAction{ID: action.ID}}
+		if expr := c.queryExpr(system, req.Subject.Type, req.Subject.ID, action.ID); expr != nil {
+			policy.Condition = *expr
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// V2PolicyAuth will do policy auth
+func (c *MemoryClient) V2PolicyAuth(system string, body interface{}) (data map[string]interface{}, err error) {
+	return c.V2PolicyAuthCtx(context.Background(), system, body)
+}
+
+// V2PolicyAuthCtx will do policy auth, evaluate the granted policies with the resources
+func (c *MemoryClient) V2PolicyAuthCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	result, err := c.V2PolicyAuthTypedCtx(ctx, system, body)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"allowed": result.Allowed}, nil
+}
+
+// V2PolicyAuthTypedCtx will do policy auth, evaluate the granted policies with the resources
+func (c *MemoryClient) V2PolicyAuthTypedCtx(
+	ctx context.Context,
+	system string,
+	body interface{},
+) (result PolicyAuthResult, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return
+	}
+	if system == "" {
+		system = req.System
+	}
+
+	result.Allowed = c.eval(system, req.Subject.Type, req.Subject.ID, req.Action.ID, req.Resources)
+	return result, nil
+}
+
+// PolicyAuth will do policy auth
+func (c *MemoryClient) PolicyAuth(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyAuthCtx(context.Background(), body)
+}
+
+// PolicyAuthCtx will do policy auth, evaluate the granted policies with the resources
+func (c *MemoryClient) PolicyAuthCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error) {
+	return c.V2PolicyAuthCtx(ctx, "", body)
+}
+
+// PolicyAuthTypedCtx will do policy auth, evaluate the granted policies with the resources
+func (c *MemoryClient) PolicyAuthTypedCtx(ctx context.Context, body interface{}) (result PolicyAuthResult, err error) {
+	return c.V2PolicyAuthTypedCtx(ctx, "", body)
+}
+
+// PolicyAuthByResources will do policy auth by resources
+func (c *MemoryClient) PolicyAuthByResources(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyAuthByResourcesCtx(context.Background(), body)
+}
+
+// PolicyAuthByResourcesCtx will do policy auth by resources, the key of the result is the resource id
+// as iam.BatchIsAllowed
+func (c *MemoryClient) PolicyAuthByResourcesCtx(
+	ctx context.Context,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	result, err := c.PolicyAuthByResourcesTypedCtx(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return boolMapToMap(result), nil
+}
+
+// PolicyAuthByResourcesTypedCtx will do policy auth by resources, the key of the result is the resource id
+// as iam.BatchIsAllowed
+func (c *MemoryClient) PolicyAuthByResourcesTypedCtx(
+	ctx context.Context,
+	body interface{},
+) (result map[string]bool, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return nil, err
+	}
+
+	result = make(map[string]bool, len(req.ResourcesList))
+	for _, resources := range req.ResourcesList {
+		allowed := c.eval(req.System, req.Subject.Type, req.Subject.ID, req.Action.ID, resources)
+		result[memoryResourceID(resources)] = allowed
+	}
+	return result, nil
+}
+
+// PolicyAuthByActions will do policy auth by actions
+func (c *MemoryClient) PolicyAuthByActions(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyAuthByActionsCtx(context.Background(), body)
+}
+
+// PolicyAuthByActionsCtx will do policy auth by actions, the key of the result is the action id
+func (c *MemoryClient) PolicyAuthByActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (data map[string]interface{}, err error) {
+	result, err := c.PolicyAuthByActionsTypedCtx(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return boolMapToMap(result), nil
+}
+
+// PolicyAuthByActionsTypedCtx will do policy auth by actions, the key of the result is the action id
+func (c *MemoryClient) PolicyAuthByActionsTypedCtx(
+	ctx context.Context,
+	body interface{},
+) (result map[string]bool, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryPolicyRequest
+	if err = decodeMemoryRequest(body, &req); err != nil {
+		return nil, err
+	}
+
+	result = make(map[string]bool, len(req.Actions))
+	for _, action := range req.Actions {
+		result[action.ID] = c.eval(req.System, req.Subject.Type, req.Subject.ID, action.ID, req.Resources)
+	}
+	return result, nil
+}
+
+// PolicyGet will get the policy detail by id
+func (c *MemoryClient) PolicyGet(policyID int64) (data map[string]interface{}, err error) {
+	return c.PolicyGetCtx(context.Background(), policyID)
+}
+
+// PolicyGetCtx will get the policy detail by id
+func (c *MemoryClient) PolicyGetCtx(ctx context.Context, policyID int64) (data map[string]interface{}, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	p, err := c.getPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	data = p.toMap()
+	data["system"] = p.system
+	data["action"] = map[string]interface{}{"id": p.actionID}
+	return data, nil
+}
+
+// PolicyGetTypedCtx will get the policy detail by id
+func (c *MemoryClient) PolicyGetTypedCtx(ctx context.Context, policyID int64) (policy Policy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	p, err := c.getPolicy(policyID)
+	if err != nil {
+		return
+	}
+
+	policy = p.toPolicy()
+	policy.Action = ActionPolicyAction{ID: p.actionID}
+	return policy, nil
+}
+
+// PolicyList will list the policies of the action, with pagination
+func (c *MemoryClient) PolicyList(body interface{}) (data map[string]interface{}, err error) {
+	return c.PolicyListCtx(context.Background(), body)
+}
+
+// PolicyListCtx will list the policies of the action, with pagination
+func (c *MemoryClient) PolicyListCtx(ctx context.Context, body interface{}) (data map[string]interface{}, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	req, count, policies, err := c.listPolicies(body)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0, len(policies))
+	for i := range policies {
+		results = append(results, policies[i].toMap())
+	}
+
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"action":    map[string]interface{}{"id": req.ActionID},
+			"timestamp": req.Timestamp,
+		},
+		"count":   count,
+		"results": results,
+	}, nil
+}
+
+// PolicyListTypedCtx will list the policies of the action, with pagination
+func (c *MemoryClient) PolicyListTypedCtx(ctx context.Context, body interface{}) (result PolicyListResult, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	req, count, policies, err := c.listPolicies(body)
+	if err != nil {
+		return
+	}
+
+	result.Metadata = PolicyListMetadata{Action: ActionPolicyAction{ID: req.ActionID}, Timestamp: req.Timestamp}
+	result.Count = count
+	result.Results = make([]Policy, 0, len(policies))
+	for i := range policies {
+		result.Results = append(result.Results, policies[i].toPolicy())
+	}
+	return result, nil
+}
+
+// PolicySubjects will query the subject of each policy
+func (c *MemoryClient) PolicySubjects(policyIDs []int64) (data []map[string]interface{}, err error) {
+	return c.PolicySubjectsCtx(context.Background(), policyIDs)
+}
+
+// PolicySubjectsCtx will query the subject of each policy, the not exists policies will be ignored
+func (c *MemoryClient) PolicySubjectsCtx(
+	ctx context.Context,
+	policyIDs []int64,
+) (data []map[string]interface{}, err error) {
+	items, err := c.PolicySubjectsTypedCtx(ctx, policyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	data = make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		data = append(data, map[string]interface{}{
+			"id": item.ID,
+			"subject": map[string]interface{}{
+				"type": item.Subject.Type, "id": item.Subject.ID, "name": item.Subject.Name,
+			},
+		})
+	}
+	return data, nil
+}
+
+// PolicySubjectsTypedCtx will query the subject of each policy, the not exists policies will be ignored
+func (c *MemoryClient) PolicySubjectsTypedCtx(
+	ctx context.Context,
+	policyIDs []int64,
+) (items []PolicySubjectItem, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items = []PolicySubjectItem{}
+	for _, id := range policyIDs {
+		for i := range c.policies {
+			if c.policies[i].id == id && (c.system == "" || c.policies[i].system == c.system) {
+				items = append(items, PolicySubjectItem{ID: id, Subject: c.policies[i].subject()})
+				break
+			}
+		}
+	}
+	return items, nil
+}
+
+// GetApplyURL will get apply url
+func (c *MemoryClient) GetApplyURL(body interface{}) (string, error) {
+	return c.GetApplyURLCtx(context.Background(), body)
+}
+
+// GetApplyURLCtx will get apply url
+func (c *MemoryClient) GetApplyURLCtx(ctx context.Context, body interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.applyURL, nil
+}
+
+// GrantResourceCreatorActions will grant the creator the actions configured in resource_creator_actions
+func (c *MemoryClient) GrantResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.GrantResourceCreatorActionsCtx(context.Background(), body)
+}
+
+// GrantResourceCreatorActionsCtx will grant the creator the actions configured in resource_creator_actions,
+// the configs are added by AddResourceCreatorActions/UpdateResourceCreatorActions
+func (c *MemoryClient) GrantResourceCreatorActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryCreatorRequest
+	if err = decodeMemoryAuthorizationRequest(body, &req); err != nil {
+		return nil, err
+	}
+	return c.grantCreator(req.System, req.Type, req.Creator, []string{req.ID})
+}
+
+// GrantBatchResourceCreatorActions will grant the creator the actions configured in resource_creator_actions
+func (c *MemoryClient) GrantBatchResourceCreatorActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.GrantBatchResourceCreatorActionsCtx(context.Background(), body)
+}
+
+// GrantBatchResourceCreatorActionsCtx will grant the creator the actions configured in resource_creator_actions
+func (c *MemoryClient) GrantBatchResourceCreatorActionsCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var req memoryCreatorRequest
+	if err = decodeMemoryAuthorizationRequest(body, &req); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(req.Instances))
+	for _, instance := range req.Instances {
+		ids = append(ids, instance.ID)
+	}
+	return c.grantCreator(req.System, req.Type, req.Creator, ids)
+}
+
+// GrantOrRevokeInstancePermission will grant or revoke the permission of the resource instances
+func (c *MemoryClient) GrantOrRevokeInstancePermission(body interface{}) (policy AuthorizationPolicy, err error) {
+	return c.GrantOrRevokeInstancePermissionCtx(context.Background(), body)
+}
+
+// GrantOrRevokeInstancePermissionCtx will grant or revoke the permission of the resource instances,
+// the instances are granted as `type.id in [ids]`
+func (c *MemoryClient) GrantOrRevokeInstancePermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policy AuthorizationPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return c.grantOrRevokeAction(body)
+}
+
+// BatchGrantOrRevokeInstancePermission will grant or revoke the permissions of the resource instances
+func (c *MemoryClient) BatchGrantOrRevokeInstancePermission(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.BatchGrantOrRevokeInstancePermissionCtx(context.Background(), body)
+}
+
+// BatchGrantOrRevokeInstancePermissionCtx will grant or revoke the permissions of the resource instances,
+// the instances are granted as `type.id in [ids]` by the id of the last node
+func (c *MemoryClient) BatchGrantOrRevokeInstancePermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return c.batchGrantOrRevokeActions(body)
+}
+
+// GrantOrRevokePathPermission will grant or revoke the permission of the topology paths
+func (c *MemoryClient) GrantOrRevokePathPermission(body interface{}) (policy AuthorizationPolicy, err error) {
+	return c.GrantOrRevokePathPermissionCtx(context.Background(), body)
+}
+
+// GrantOrRevokePathPermissionCtx will grant or revoke the permission of the topology paths,
+// the paths are granted as `type._bk_iam_path_ starts_with path`
+func (c *MemoryClient) GrantOrRevokePathPermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policy AuthorizationPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return c.grantOrRevokeAction(body)
+}
+
+// BatchGrantOrRevokePathPermission will grant or revoke the permissions of the topology paths
+func (c *MemoryClient) BatchGrantOrRevokePathPermission(body interface{}) (policies []AuthorizationPolicy, err error) {
+	return c.BatchGrantOrRevokePathPermissionCtx(context.Background(), body)
+}
+
+// BatchGrantOrRevokePathPermissionCtx will grant or revoke the permissions of the topology paths,
+// the paths are granted as `type._bk_iam_path_ starts_with path`
+func (c *MemoryClient) BatchGrantOrRevokePathPermissionCtx(
+	ctx context.Context,
+	body interface{},
+) (policies []AuthorizationPolicy, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return c.batchGrantOrRevokeActions(body)
+}
+
+// ModelQuery returns the model of the system, registered by RegisterModel or the model apis
+func (c *MemoryClient) ModelQuery(system string) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model, ok := c.models[system]
+	if !ok {
+		return map[string]interface{}{}, memoryAPIError(GET, http.StatusNotFound, "system %s not found", system)
+	}
+
+	data := make(map[string]interface{}, len(model))
+	for k, v := range model {
+		data[k] = v
+	}
+	return data, nil
+}
+
+// ModelQueryTypedCtx returns the model of the system, decoded into SystemModel
+func (c *MemoryClient) ModelQueryTypedCtx(ctx context.Context, system string) (model SystemModel, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	data, err := c.ModelQuery(system)
+	if err != nil {
+		return
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{TagName: "json", Result: &model})
+	if err != nil {
+		return
+	}
+	if err = decoder.Decode(data); err != nil {
+		err = memoryAPIError(GET, http.StatusInternalServerError, "decode model fail: %s", err)
+	}
+	return
+}
+
+// AddSystem adds the system
+func (c *MemoryClient) AddSystem(body interface{}) error {
+	ids := modelIDs(body)
+	if len(ids) != 1 {
+		return memoryAPIError(POST, http.StatusBadRequest, "system id is empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.record("AddSystem", ids[0], ids, body)
+	if _, ok := c.models[ids[0]]; ok {
+		return memoryAPIError(POST, http.StatusConflict, "system %s already exists", ids[0])
+	}
+	c.ensureModel(ids[0])["base_info"] = map[string]interface{}{"id": ids[0]}
+	return nil
+}
+
+// UpdateSystem updates the system
+func (c *MemoryClient) UpdateSystem(system string, body interface{}) error {
+	return c.mutate("UpdateSystem", PUT, system, []string{system}, body, func(model map[string]interface{}) error {
+		return nil
+	})
+}
+
+// AddResourceType adds the resource types
+func (c *MemoryClient) AddResourceType(system string, body interface{}) error {
+	return c.addItems("AddResourceType", system, modelKeyResourceTypes, body)
+}
+
+// UpdateResourceType updates the resource type
+func (c *MemoryClient) UpdateResourceType(system, resourceTypeID string, body interface{}) error {
+	return c.updateItem("UpdateResourceType", system, modelKeyResourceTypes, resourceTypeID, body)
+}
+
+// BatchDeleteResourceType deletes the resource types
+func (c *MemoryClient) BatchDeleteResourceType(system string, resourceTypeIDs ...string) error {
+	return c.deleteItems("BatchDeleteResourceType", system, modelKeyResourceTypes, resourceTypeIDs)
+}
+
+// AddInstanceSelection adds the instance selections
+func (c *MemoryClient) AddInstanceSelection(system string, body interface{}) error {
+	return c.addItems("AddInstanceSelection", system, modelKeyInstanceSelections, body)
+}
+
+// UpdateInstanceSelection updates the instance selection
+func (c *MemoryClient) UpdateInstanceSelection(system, instanceSelectionID string, body interface{}) error {
+	return c.updateItem("UpdateInstanceSelection", system, modelKeyInstanceSelections, instanceSelectionID, body)
+}
+
+// BatchDeleteInstanceSelection deletes the instance selections
+func (c *MemoryClient) BatchDeleteInstanceSelection(system string, instanceSelectionIDs ...string) error {
+	return c.deleteItems("BatchDeleteInstanceSelection", system, modelKeyInstanceSelections, instanceSelectionIDs)
+}
+
+// AddAction adds the actions
+func (c *MemoryClient) AddAction(system string, body interface{}) error {
+	return c.addItems("AddAction", system, modelKeyActions, body)
+}
+
+// UpdateAction updates the action
+func (c *MemoryClient) UpdateAction(system, actionID string, body interface{}) error {
+	return c.updateItem("UpdateAction", system, modelKeyActions, actionID, body)
+}
+
+// BatchDeleteAction deletes the actions
+func (c *MemoryClient) BatchDeleteAction(system string, actionIDs ...string) error {
+	return c.deleteItems("BatchDeleteAction", system, modelKeyActions, actionIDs)
+}
+
+// AddActionGroups adds the action groups
+func (c *MemoryClient) AddActionGroups(system string, body interface{}) error {
+	return c.setConfig("AddActionGroups", POST, system, "action_groups", body)
+}
+
+// UpdateActionGroups updates the action groups
+func (c *MemoryClient) UpdateActionGroups(system string, body interface{}) error {
+	return c.setConfig("UpdateActionGroups", PUT, system, "action_groups", body)
+}
+
+// AddResourceCreatorActions adds the resource creator actions
+func (c *MemoryClient) AddResourceCreatorActions(system string, body interface{}) error {
+	return c.setConfig("AddResourceCreatorActions", POST, system, "resource_creator_actions", body)
+}
+
+// UpdateResourceCreatorActions updates the resource creator actions
+func (c *MemoryClient) UpdateResourceCreatorActions(system string, body interface{}) error {
+	return c.setConfig("UpdateResourceCreatorActions", PUT, system, "resource_creator_actions", body)
+}
+
+// AddCommonActions adds the common actions
+func (c *MemoryClient) AddCommonActions(system string, body interface{}) error {
+	return c.setConfig("AddCommonActions", POST, system, "common_actions", body)
+}
+
+// UpdateCommonActions updates the common actions
+func (c *MemoryClient) UpdateCommonActions(system string, body interface{}) error {
+	return c.setConfig("UpdateCommonActions", PUT, system, "common_actions", body)
+}
+
+// AddFeatureShieldRules adds the feature shield rules
+func (c *MemoryClient) AddFeatureShieldRules(system string, body interface{}) error {
+	return c.setConfig("AddFeatureShieldRules", POST, system, "feature_shield_rules", body)
+}
+
+// UpdateFeatureShieldRules updates the feature shield rules
+func (c *MemoryClient) UpdateFeatureShieldRules(system string, body interface{}) error {
+	return c.setConfig("UpdateFeatureShieldRules", PUT, system, "feature_shield_rules", body)
+}
+
+// queryExpr returns the expression of the subject and action, nil if no policy
+func (c *MemoryClient) queryExpr(system, subjectType, subjectID, actionID string) *expression.ExprCell {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().Unix()
+	exprs := []expression.ExprCell{}
+	for _, p := range c.policies {
+		if p.match(system, subjectType, subjectID, actionID) && p.expiredAt > now {
+			exprs = append(exprs, p.expr)
+		}
+	}
+
+	switch len(exprs) {
+	case 0:
+		return nil
+	case 1:
+		return &exprs[0]
+	default:
+		return &expression.ExprCell{OP: operator.OR, Content: exprs}
+	}
+}
+
+// eval evaluates the granted policies of the subject and action with the resources
+func (c *MemoryClient) eval(system, subjectType, subjectID, actionID string, resources []memoryResource) bool {
+	expr := c.queryExpr(system, subjectType, subjectID, actionID)
+	if expr == nil {
+		return false
+	}
+
+	objSet := expression.NewObjectSet()
+	for _, r := range resources {
+		attrs := make(map[string]interface{}, len(r.Attribute)+1)
+		attrs["id"] = r.ID
+		for k, v := range r.Attribute {
+			attrs[k] = v
+		}
+		objSet.Set(r.Type, attrs)
+	}
+	return expr.Eval(objSet)
+}
+
+// memoryResourceID is the key of the resources in the auth by resources response
+func memoryResourceID(resources []memoryResource) string {
+	if len(resources) == 1 {
+		return resources[0].ID
+	}
+
+	nodeIDs := make([]string, 0, len(resources))
+	for _, r := range resources {
+		nodeIDs = append(nodeIDs, r.Type+","+r.ID)
+	}
+	return strings.Join(nodeIDs, "/")
+}
+
+// getPolicy returns the copy of the policy by id, not found error if not exists
+func (c *MemoryClient) getPolicy(policyID int64) (memoryPolicy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range c.policies {
+		if p.id == policyID && (c.system == "" || p.system == c.system) {
+			return p, nil
+		}
+	}
+	return memoryPolicy{}, memoryAPIError(GET, http.StatusNotFound, "policy %d not found", policyID)
+}
+
+// listPolicies returns the request with defaults, the count of the matched policies and the policies of the page,
+// the policies are filtered by the system(see SetSystem), the action and the timestamp
+func (c *MemoryClient) listPolicies(
+	body interface{},
+) (req memoryPolicyListRequest, count int64, policies []memoryPolicy, err error) {
+	if err = mapstructure.Decode(body, &req); err != nil {
+		err = memoryAPIError(GET, http.StatusBadRequest, "invalid body: %s", err)
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = memoryDefaultPageSize
+	}
+	if req.Timestamp <= 0 {
+		req.Timestamp = time.Now().Unix()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the policies not expired at the timestamp, same as iam
+	matched := []memoryPolicy{}
+	systems := map[string]struct{}{}
+	for _, p := range c.policies {
+		if p.actionID == req.ActionID && (c.system == "" || p.system == c.system) && p.expiredAt > req.Timestamp {
+			matched = append(matched, p)
+			systems[p.system] = struct{}{}
+		}
+	}
+	if len(systems) > 1 {
+		err = memoryAPIError(GET, http.StatusBadRequest,
+			"the policies of action %s belong to multiple systems, set the system by SetSystem", req.ActionID)
+		return
+	}
+
+	start := (req.Page - 1) * req.PageSize
+	for i := start; i < start+req.PageSize && i < int64(len(matched)); i++ {
+		policies = append(policies, matched[i])
+	}
+	return req, int64(len(matched)), policies, nil
+}
+
+func (p *memoryPolicy) match(system, subjectType, subjectID, actionID string) bool {
+	return p.system == system && p.subjectType == subjectType && p.subjectID == subjectID && p.actionID == actionID
+}
+
+func (p *memoryPolicy) subject() PolicySubject {
+	return PolicySubject{Type: p.subjectType, ID: p.subjectID, Name: p.subjectID}
+}
+
+// toPolicy converts the policy into the item of the policy list response
+func (p *memoryPolicy) toPolicy() Policy {
+	return Policy{
+		ID:         p.id,
+		Version:    "1",
+		Subject:    p.subject(),
+		Expression: p.expr,
+		ExpiredAt:  p.expiredAt,
+	}
+}
+
+// toMap converts the policy into the map as the policy list response
+func (p *memoryPolicy) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"version":    "1",
+		"id":         p.id,
+		"subject":    map[string]interface{}{"type": p.subjectType, "id": p.subjectID, "name": p.subjectID},
+		"expression": exprToMap(p.expr),
+		"expired_at": p.expiredAt,
+	}
+}
+
+// grantCreator grants the creator the configured actions of the resource type on the instances
+func (c *MemoryClient) grantCreator(system, resourceType, creator string, ids []string) ([]AuthorizationPolicy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var data struct {
+		Config []memoryCreatorConfig `json:"config"`
+	}
+	if model, ok := c.models[system]; ok && model["resource_creator_actions"] != nil {
+		if err := decodeTyped(model["resource_creator_actions"], &data); err != nil {
+			return nil, memoryAPIError(POST, http.StatusInternalServerError, "invalid resource_creator_actions: %s", err)
+		}
+	}
+
+	leaf := idsExpr(resourceType, ids)
+	policies := []AuthorizationPolicy{}
+	if config := findCreatorConfig(data.Config, resourceType); config != nil {
+		for _, action := range config.Actions {
+			policyID := c.grantLeaves(system, "user", creator, action.ID, []expression.ExprCell{leaf}, 0)
+			policies = append(policies, AuthorizationPolicy{Action: ActionPolicyAction{ID: action.ID}, PolicyID: policyID})
+		}
+	}
+	return policies, nil
+}
+
+// grantOrRevokeAction grants or revokes the permission of the action in the request
+func (c *MemoryClient) grantOrRevokeAction(body interface{}) (policy AuthorizationPolicy, err error) {
+	var req memoryPermissionRequest
+	if err = decodeMemoryAuthorizationRequest(body, &req); err != nil {
+		return
+	}
+
+	policyID, err := c.grantOrRevoke(&req, req.Action.ID)
+	if err != nil {
+		return
+	}
+	return AuthorizationPolicy{Action: ActionPolicyAction{ID: req.Action.ID}, PolicyID: policyID}, nil
+}
+
+// batchGrantOrRevokeActions grants or revokes the permissions of each action in the request
+func (c *MemoryClient) batchGrantOrRevokeActions(body interface{}) (policies []AuthorizationPolicy, err error) {
+	var req memoryPermissionRequest
+	if err = decodeMemoryAuthorizationRequest(body, &req); err != nil {
+		return nil, err
+	}
+
+	policies = make([]AuthorizationPolicy, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		policyID, err := c.grantOrRevoke(&req, action.ID)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, AuthorizationPolicy{Action: ActionPolicyAction{ID: action.ID}, PolicyID: policyID})
+	}
+	return policies, nil
+}
+
+// grantOrRevoke grants or revokes the resources of the request for the action, return the policy id
+func (c *MemoryClient) grantOrRevoke(req *memoryPermissionRequest, actionID string) (int64, error) {
+	leaves := req.leaves()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Operate {
+	case "grant":
+		return c.grantLeaves(req.System, req.Subject.Type, req.Subject.ID, actionID, leaves, req.ExpiredAt), nil
+	case "revoke":
+		return c.revokeLeaves(req.System, req.Subject.Type, req.Subject.ID, actionID, leaves), nil
+	default:
+		return 0, memoryAPIError(POST, http.StatusBadRequest, "invalid operate `%s`", req.Operate)
+	}
+}
+
+// grantLeaves merges the leaves into the policy of the subject and action, create the policy if not exists,
+// must be called with the lock
+func (c *MemoryClient) grantLeaves(
+	system, subjectType, subjectID, actionID string,
+	leaves []expression.ExprCell,
+	expiredAt int64,
+) int64 {
+	if expiredAt <= 0 {
+		expiredAt = MemoryPolicyExpiredAt
+	}
+
+	for i := range c.policies {
+		p := &c.policies[i]
+		if p.match(system, subjectType, subjectID, actionID) {
+			for _, leaf := range leaves {
+				p.expr = mergeExpr(p.expr, leaf)
+			}
+			if expiredAt > p.expiredAt {
+				p.expiredAt = expiredAt
+			}
+			return p.id
+		}
+	}
+
+	expr := leaves[0]
+	for _, leaf := range leaves[1:] {
+		expr = mergeExpr(expr, leaf)
+	}
+	c.policyID++
+	c.policies = append(c.policies, memoryPolicy{
+		id:          c.policyID,
+		system:      system,
+		subjectType: subjectType,
+		subjectID:   subjectID,
+		actionID:    actionID,
+		expr:        expr,
+		expiredAt:   expiredAt,
+	})
+	return c.policyID
+}
+
+// revokeLeaves removes the leaves from the policies of the subject and action, the empty policies are deleted,
+// return the id of the first policy, 0 if no policy; must be called with the lock
+func (c *MemoryClient) revokeLeaves(system, subjectType, subjectID, actionID string, leaves []expression.ExprCell) int64 {
+	var policyID int64
+	policies := c.policies[:0]
+	for _, p := range c.policies {
+		if !p.match(system, subjectType, subjectID, actionID) {
+			policies = append(policies, p)
+			continue
+		}
+		if policyID == 0 {
+			policyID = p.id
+		}
+
+		expr, ok := p.expr, true
+		for _, leaf := range leaves {
+			if expr, ok = revokeExpr(expr, leaf); !ok {
+				break
+			}
+		}
+		if ok {
+			p.expr = expr
+			policies = append(policies, p)
+		}
+	}
+	c.policies = policies
+	return policyID
+}
+
+// record records the mutation, must be called with the lock
+func (c *MemoryClient) record(operation, system string, ids []string, body interface{}) {
+	c.mutations = append(c.mutations, ModelMutation{
+		Operation: operation,
+		System:    system,
+		IDs:       ids,
+		Body:      body,
+	})
+}
+
+// ensureModel returns the model of the system, create it if not exists, must be called with the lock
+func (c *MemoryClient) ensureModel(system string) map[string]interface{} {
+	model, ok := c.models[system]
+	if !ok {
+		model = map[string]interface{}{
+			"base_info":                map[string]interface{}{"id": system},
+			modelKeyResourceTypes:      []map[string]interface{}{},
+			modelKeyInstanceSelections: []map[string]interface{}{},
+			modelKeyActions:            []map[string]interface{}{},
+		}
+		c.models[system] = model
+	}
+	return model
+}
+
+// mutate records the mutation and applies it to the model of the system, return not found if the system not exists
+func (c *MemoryClient) mutate(
+	operation string, method Method,
+	system string, ids []string,
+	body interface{},
+	apply func(model map[string]interface{}) error,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.record(operation, system, ids, body)
+	model, ok := c.models[system]
+	if !ok {
+		return memoryAPIError(method, http.StatusNotFound, "system %s not found", system)
+	}
+	return apply(model)
+}
+
+func (c *MemoryClient) addItems(operation, system, key string, body interface{}) error {
+	ids := modelIDs(body)
+	return c.mutate(operation, POST, system, ids, body, func(model map[string]interface{}) error {
+		items := model[key].([]map[string]interface{})
+		for _, id := range ids {
+			if indexOfID(items, id) != -1 {
+				return memoryAPIError(POST, http.StatusConflict, "%s %s already exists", key, id)
+			}
+		}
+		model[key] = append(items, idItems(ids)...)
+		return nil
+	})
+}
+
+func (c *MemoryClient) updateItem(operation, system, key, id string, body interface{}) error {
+	return c.mutate(operation, PUT, system, []string{id}, body, func(model map[string]interface{}) error {
+		if indexOfID(model[key].([]map[string]interface{}), id) == -1 {
+			return memoryAPIError(PUT, http.StatusNotFound, "%s %s not found", key, id)
+		}
+		return nil
+	})
+}
+
+func (c *MemoryClient) deleteItems(operation, system, key string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.mutate(operation, DELETE, system, ids, nil, func(model map[string]interface{}) error {
+		items := []map[string]interface{}{}
+		for _, item := range model[key].([]map[string]interface{}) {
+			if indexOfID(idItems(ids), item["id"].(string)) == -1 {
+				items = append(items, item)
+			}
+		}
+		model[key] = items
+		return nil
+	})
+}
+
+func (c *MemoryClient) setConfig(operation string, method Method, system, key string, body interface{}) error {
+	return c.mutate(operation, method, system, nil, body, func(model map[string]interface{}) error {
+		model[key] = body
+		return nil
+	})
+}
+
+func decodeMemoryRequest(body interface{}, req *memoryPolicyRequest) error {
+	if err := mapstructure.Decode(body, req); err != nil {
+		return memoryAPIError(POST, http.StatusBadRequest, "invalid body: %s", err)
+	}
+	return nil
+}
+
+// decodeMemoryAuthorizationRequest decodes the iam authorization request by json, the requests have json tags only
+func decodeMemoryAuthorizationRequest(body interface{}, req interface{}) error {
+	if err := decodeTyped(body, req); err != nil {
+		return memoryAPIError(POST, http.StatusBadRequest, "invalid body: %s", err)
+	}
+	return nil
+}
+
+// leaves returns the expression of each resource type in the request,
+// the instances as `type.id in [ids]` and the paths as `type._bk_iam_path_ starts_with path`
+func (r *memoryPermissionRequest) leaves() []expression.ExprCell {
+	types := []string{}
+	ids := map[string][]string{}
+	addID := func(resourceType, id string) {
+		if _, ok := ids[resourceType]; !ok {
+			types = append(types, resourceType)
+		}
+		ids[resourceType] = append(ids[resourceType], id)
+	}
+
+	paths := []expression.ExprCell{}
+	for _, resource := range r.Resources {
+		if resource.ID != "" {
+			addID(resource.Type, resource.ID)
+		}
+		for _, instance := range resource.Instances {
+			if len(instance) > 0 {
+				addID(resource.Type, instance[len(instance)-1].ID)
+			}
+		}
+		for _, path := range append([][]memoryResourceNode{resource.Path}, resource.Paths...) {
+			if len(path) > 0 {
+				paths = append(paths, expression.ExprCell{
+					OP:    operator.StartsWith,
+					Field: resource.Type + expression.KeywordBKIAMPathFieldSuffix,
+					Value: memoryPathString(path),
+				})
+			}
+		}
+	}
+
+	leaves := make([]expression.ExprCell, 0, len(types)+len(paths))
+	for _, resourceType := range types {
+		leaves = append(leaves, idsExpr(resourceType, ids[resourceType]))
+	}
+	return append(leaves, paths...)
+}
+
+// memoryPathString renders the path as `_bk_iam_path_`, the last node `*` means all the instances under the parent,
+// e.g. from [biz,1 set,*] to `/biz,1/set,`
+func memoryPathString(path []memoryResourceNode) string {
+	var b strings.Builder
+	b.WriteString("/")
+	for i, node := range path {
+		b.WriteString(node.Type)
+		b.WriteString(",")
+		if i == len(path)-1 && node.ID == "*" {
+			break
+		}
+		b.WriteString(node.ID)
+		b.WriteString("/")
+	}
+	return b.String()
+}
+
+func idsExpr(resourceType string, ids []string) expression.ExprCell {
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id)
+	}
+	return expression.ExprCell{OP: operator.In, Field: resourceType + ".id", Value: values}
+}
+
+// mergeExpr merges the granted leaf into the expression, the ids of the same field are combined into one `in`
+func mergeExpr(expr, leaf expression.ExprCell) expression.ExprCell {
+	switch {
+	case expr.OP == operator.Any:
+		return expr
+	case expr.OP == operator.OR:
+		content := make([]expression.ExprCell, 0, len(expr.Content)+1)
+		merged := false
+		for _, e := range expr.Content {
+			if !merged && canMergeExpr(e, leaf) {
+				e, merged = mergeExpr(e, leaf), true
+			}
+			content = append(content, e)
+		}
+		if !merged {
+			content = append(content, leaf)
+		}
+		return expression.ExprCell{OP: operator.OR, Content: content}
+	case reflect.DeepEqual(expr, leaf):
+		return expr
+	case canMergeExpr(expr, leaf):
+		values := append([]interface{}{}, expr.Value.([]interface{})...)
+		for _, v := range leaf.Value.([]interface{}) {
+			if !containsValue(values, v) {
+				values = append(values, v)
+			}
+		}
+		return expression.ExprCell{OP: operator.In, Field: expr.Field, Value: values}
+	default:
+		return expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{expr, leaf}}
+	}
+}
+
+func canMergeExpr(expr, leaf expression.ExprCell) bool {
+	return reflect.DeepEqual(expr, leaf) ||
+		(expr.OP == operator.In && leaf.OP == operator.In && expr.Field == leaf.Field && isValueSlice(expr.Value))
+}
+
+// revokeExpr removes the revoked leaf from the expression, return false if nothing remains
+func revokeExpr(expr, leaf expression.ExprCell) (expression.ExprCell, bool) {
+	switch {
+	case reflect.DeepEqual(expr, leaf):
+		return expression.ExprCell{}, false
+	case expr.OP == operator.OR:
+		content := make([]expression.ExprCell, 0, len(expr.Content))
+		for _, e := range expr.Content {
+			if e, ok := revokeExpr(e, leaf); ok {
+				content = append(content, e)
+			}
+		}
+		switch len(content) {
+		case 0:
+			return expression.ExprCell{}, false
+		case 1:
+			return content[0], true
+		default:
+			return expression.ExprCell{OP: operator.OR, Content: content}, true
+		}
+	case expr.OP == operator.In && leaf.OP == operator.In && expr.Field == leaf.Field && isValueSlice(expr.Value):
+		values := []interface{}{}
+		for _, v := range expr.Value.([]interface{}) {
+			if !containsValue(leaf.Value.([]interface{}), v) {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return expression.ExprCell{}, false
+		}
+		return expression.ExprCell{OP: operator.In, Field: expr.Field, Value: values}, true
+	default:
+		return expr, true
+	}
+}
+
+// findCreatorConfig finds the config of the resource type, including the sub resource types
+func findCreatorConfig(configs []memoryCreatorConfig, resourceType string) *memoryCreatorConfig {
+	for i := range configs {
+		if configs[i].ID == resourceType {
+			return &configs[i]
+		}
+		if config := findCreatorConfig(configs[i].SubResourceTypes, resourceType); config != nil {
+			return config
+		}
+	}
+	return nil
+}
+
+func isValueSlice(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// exprToMap converts the expression into the map as the policy query response, which can be decoded by mapstructure
+func exprToMap(expr expression.ExprCell) map[string]interface{} {
+	content := make([]interface{}, 0, len(expr.Content))
+	for _, c := range expr.Content {
+		content = append(content, exprToMap(c))
+	}
+	return map[string]interface{}{
+		"op":      string(expr.OP),
+		"content": content,
+		"field":   expr.Field,
+		"value":   expr.Value,
+	}
+}
+
+// boolMapToMap converts the typed auth result into the map as the policy auth response
+func boolMapToMap(result map[string]bool) map[string]interface{} {
+	data := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		data[k] = v
+	}
+	return data
+}
+
+// modelIDs returns the ids of the model body, which can be a struct with ID field, a map with id key, or a slice of them
+func modelIDs(body interface{}) []string {
+	v := reflect.ValueOf(body)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		ids := []string{}
+		for i := 0; i < v.Len(); i++ {
+			ids = append(ids, modelIDs(v.Index(i).Interface())...)
+		}
+		return ids
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		if idV := v.MapIndex(reflect.ValueOf("id").Convert(v.Type().Key())); idV.IsValid() {
+			if id, ok := idV.Interface().(string); ok && id != "" {
+				return []string{id}
+			}
+		}
+	case reflect.Struct:
+		if f := v.FieldByName("ID"); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+			return []string{f.String()}
+		}
+	}
+	return nil
+}
+
+func idItems(ids []string) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, map[string]interface{}{"id": id})
+	}
+	return items
+}
+
+func indexOfID(items []map[string]interface{}, id string) int {
+	for i, item := range items {
+		if item["id"] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func memoryAPIError(method Method, status int, format string, args ...interface{}) *APIError {
+	return &APIError{
+		StatusCode: status,
+		Code:       iamCodePrefix + status,
+		Message:    fmt.Sprintf(format, args...),
+		Method:     method,
+		Path:       "memory",
+	}
+}
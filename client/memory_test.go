@@ -0,0 +1,315 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("MemoryClient", func() {
+	var cli *client.MemoryClient
+
+	body := map[string]interface{}{
+		"system":  "demo",
+		"subject": map[string]interface{}{"type": "user", "id": "admin"},
+		"action":  map[string]interface{}{"id": "edit"},
+		"actions": []map[string]interface{}{{"id": "edit"}, {"id": "view"}},
+	}
+
+	BeforeEach(func() {
+		cli = client.NewMemoryClient()
+	})
+
+	Context("policy", func() {
+		It("no policy", func() {
+			data, err := cli.V2PolicyQueryCtx(context.Background(), "demo", body)
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), data)
+		})
+
+		It("grant", func() {
+			cli.Grant("demo", "user", "admin", "edit", expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "1"})
+			cli.GrantAny("demo", "user", "admin", "view")
+
+			data, err := cli.V2PolicyQuery("demo", body)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "eq", data["op"])
+			assert.Equal(GinkgoT(), "app.id", data["field"])
+
+			cli.Grant("demo", "user", "admin", "edit", expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "2"})
+			data, err = cli.V2PolicyQuery("demo", body)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "OR", data["op"])
+			assert.Len(GinkgoT(), data["content"], 2)
+
+			policies, err := cli.V2PolicyQueryByActions("demo", body)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), policies, 2)
+			assert.Equal(GinkgoT(), "any", policies[1]["condition"].(map[string]interface{})["op"])
+
+			cli.RevokeAll()
+			data, err = cli.V2PolicyQuery("demo", body)
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), data)
+		})
+
+		It("list and subjects", func() {
+			id1 := cli.GrantAny("demo", "user", "admin", "edit")
+			id2 := cli.GrantAny("demo", "user", "tom", "edit")
+			cli.GrantAny("demo", "user", "admin", "view")
+
+			data, err := cli.PolicyList(map[string]interface{}{"action_id": "edit", "page": 2, "page_size": 1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(2), data["count"])
+			results := data["results"].([]interface{})
+			assert.Len(GinkgoT(), results, 1)
+			assert.Equal(GinkgoT(), id2, results[0].(map[string]interface{})["id"])
+
+			policy, err := cli.PolicyGet(id1)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]interface{}{"id": "edit"}, policy["action"])
+
+			_, err = cli.PolicyGet(100)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+
+			subjects, err := cli.PolicySubjects([]int64{id2, 100})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []map[string]interface{}{{
+				"id":      id2,
+				"subject": map[string]interface{}{"type": "user", "id": "tom", "name": "tom"},
+			}}, subjects)
+		})
+	})
+
+	It("ctx done", func() {
+		cli.GrantAny("demo", "user", "admin", "edit")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := cli.V2PolicyQueryCtx(ctx, "demo", body)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+		_, err = cli.V2PolicyAuthTypedCtx(ctx, "demo", body)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+		_, err = cli.PolicyAuthByActionsCtx(ctx, body)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+		_, err = cli.GetTokenCtx(ctx)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+		_, err = cli.GetApplyURLCtx(ctx, body)
+		assert.ErrorIs(GinkgoT(), err, context.Canceled)
+
+		ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		_, err = cli.PolicyListTypedCtx(ctx, map[string]interface{}{"system": "demo", "action_id": "edit"})
+		assert.ErrorIs(GinkgoT(), err, context.DeadlineExceeded)
+		_, err = cli.ModelQueryTypedCtx(ctx, "demo")
+		assert.ErrorIs(GinkgoT(), err, context.DeadlineExceeded)
+	})
+
+	Context("authorization", func() {
+		subject := map[string]interface{}{"type": "user", "id": "admin"}
+		authBody := func(id string) map[string]interface{} {
+			return map[string]interface{}{
+				"system":    "demo",
+				"subject":   subject,
+				"action":    map[string]interface{}{"id": "edit"},
+				"resources": []map[string]interface{}{{"system": "demo", "type": "app", "id": id}},
+			}
+		}
+		instanceBody := func(operate string, ids ...string) map[string]interface{} {
+			resources := []map[string]interface{}{}
+			for _, id := range ids {
+				resources = append(resources, map[string]interface{}{"system": "demo", "type": "app", "id": id, "name": id})
+			}
+			return map[string]interface{}{
+				"operate":   operate,
+				"system":    "demo",
+				"subject":   subject,
+				"action":    map[string]interface{}{"id": "edit"},
+				"resources": resources,
+			}
+		}
+		allowed := func(id string) bool {
+			result, err := cli.V2PolicyAuthTypedCtx(context.Background(), "demo", authBody(id))
+			assert.NoError(GinkgoT(), err)
+			return result.Allowed
+		}
+
+		It("grant and revoke instance permission", func() {
+			policy, err := cli.GrantOrRevokeInstancePermission(instanceBody("grant", "1"))
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "edit", policy.Action.ID)
+			assert.True(GinkgoT(), allowed("1"))
+			assert.False(GinkgoT(), allowed("2"))
+
+			// merged into the same policy
+			policy2, err := cli.GrantOrRevokeInstancePermission(instanceBody("grant", "2", "3"))
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), policy.PolicyID, policy2.PolicyID)
+			p, err := cli.PolicyGetTypedCtx(context.Background(), policy.PolicyID)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expression.ExprCell{
+				OP: operator.In, Field: "app.id", Value: []interface{}{"1", "2", "3"},
+			}, p.Expression)
+
+			_, err = cli.GrantOrRevokeInstancePermission(instanceBody("revoke", "1", "3"))
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed("1"))
+			assert.True(GinkgoT(), allowed("2"))
+
+			// the empty policy is deleted
+			_, err = cli.GrantOrRevokeInstancePermission(instanceBody("revoke", "2"))
+			assert.NoError(GinkgoT(), err)
+			_, err = cli.PolicyGet(policy.PolicyID)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+
+			_, err = cli.GrantOrRevokeInstancePermission(instanceBody("", "1"))
+			assert.True(GinkgoT(), errors.Is(err, client.ErrBadRequest))
+		})
+
+		It("grant and revoke path permission in batch", func() {
+			body := map[string]interface{}{
+				"operate": "grant",
+				"system":  "demo",
+				"subject": subject,
+				"actions": []map[string]interface{}{{"id": "edit"}, {"id": "view"}},
+				"resources": []map[string]interface{}{{
+					"system": "demo",
+					"type":   "app",
+					"paths": [][]map[string]interface{}{
+						{{"type": "biz", "id": "1", "name": "biz1"}, {"type": "app", "id": "*"}},
+					},
+				}},
+			}
+			policies, err := cli.BatchGrantOrRevokePathPermission(body)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), policies, 2)
+			assert.Equal(GinkgoT(), "view", policies[1].Action.ID)
+
+			p, err := cli.PolicyGetTypedCtx(context.Background(), policies[0].PolicyID)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expression.ExprCell{
+				OP: operator.StartsWith, Field: "app._bk_iam_path_", Value: "/biz,1/app,",
+			}, p.Expression)
+
+			body["operate"] = "revoke"
+			_, err = cli.BatchGrantOrRevokePathPermission(body)
+			assert.NoError(GinkgoT(), err)
+			_, err = cli.PolicyGet(policies[1].PolicyID)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+		})
+
+		It("grant resource creator actions", func() {
+			cli.RegisterModel("demo", []string{"app"}, nil, []string{"edit", "view"})
+			err := cli.AddResourceCreatorActions("demo", map[string]interface{}{
+				"config": []map[string]interface{}{{
+					"id":      "biz",
+					"actions": []map[string]interface{}{{"id": "view"}},
+					"sub_resource_types": []map[string]interface{}{{
+						"id":      "app",
+						"actions": []map[string]interface{}{{"id": "edit"}, {"id": "view"}},
+					}},
+				}},
+			})
+			assert.NoError(GinkgoT(), err)
+
+			policies, err := cli.GrantBatchResourceCreatorActions(map[string]interface{}{
+				"system":    "demo",
+				"type":      "app",
+				"creator":   "admin",
+				"instances": []map[string]interface{}{{"id": "1", "name": "app1"}, {"id": "2", "name": "app2"}},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), policies, 2)
+			assert.True(GinkgoT(), allowed("1"))
+			assert.True(GinkgoT(), allowed("2"))
+
+			// no config of the type
+			policies, err = cli.GrantResourceCreatorActions(map[string]interface{}{
+				"system": "demo", "type": "host", "id": "1", "name": "host1", "creator": "admin",
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), policies)
+		})
+
+		It("expired and filtered", func() {
+			body := instanceBody("grant", "1")
+			body["expired_at"] = time.Now().Add(-time.Minute).Unix()
+			_, err := cli.GrantOrRevokeInstancePermission(body)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed("1"))
+
+			list, err := cli.PolicyListTypedCtx(context.Background(), map[string]interface{}{"action_id": "edit"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(0), list.Count)
+			list, err = cli.PolicyListTypedCtx(context.Background(), map[string]interface{}{
+				"action_id": "edit", "timestamp": time.Now().Add(-time.Hour).Unix(),
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(1), list.Count)
+
+			// multiple systems
+			cli.GrantAny("demo", "user", "admin", "edit")
+			cli.GrantAny("other", "user", "admin", "edit")
+			_, err = cli.PolicyListTypedCtx(context.Background(), map[string]interface{}{"action_id": "edit"})
+			assert.True(GinkgoT(), errors.Is(err, client.ErrBadRequest))
+
+			cli.SetSystem("other")
+			list, err = cli.PolicyListTypedCtx(context.Background(), map[string]interface{}{"action_id": "edit"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(1), list.Count)
+			assert.Len(GinkgoT(), list.Results, 1)
+		})
+	})
+
+	Context("model", func() {
+		It("register and mutate", func() {
+			cli.RegisterModel("demo", []string{"app"}, nil, []string{"edit"})
+
+			err := cli.AddResourceType("demo", []map[string]interface{}{{"id": "host"}})
+			assert.NoError(GinkgoT(), err)
+			err = cli.AddResourceType("demo", []map[string]interface{}{{"id": "app"}})
+			assert.True(GinkgoT(), errors.Is(err, client.ErrConflict))
+			err = cli.UpdateAction("demo", "view", map[string]interface{}{"id": "view"})
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+			assert.NoError(GinkgoT(), cli.BatchDeleteAction("demo", "edit"))
+
+			model, err := cli.ModelQuery("demo")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []map[string]interface{}{{"id": "app"}, {"id": "host"}}, model["resource_types"])
+			assert.Empty(GinkgoT(), model["actions"])
+
+			mutations := cli.Mutations()
+			assert.Len(GinkgoT(), mutations, 4)
+			assert.Equal(GinkgoT(), "AddResourceType", mutations[0].Operation)
+			assert.Equal(GinkgoT(), []string{"host"}, mutations[0].IDs)
+			assert.Equal(GinkgoT(), "BatchDeleteAction", mutations[3].Operation)
+		})
+
+		It("system not found", func() {
+			_, err := cli.ModelQuery("demo")
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+
+			err = cli.AddAction("demo", map[string]interface{}{"id": "edit"})
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+		})
+	})
+})
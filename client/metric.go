@@ -12,6 +12,7 @@
 package client
 
 import (
+	"net/http"
 	"strconv"
 	"time"
 
@@ -24,16 +25,46 @@ import (
 // CallbackFunc is the func object of http callback
 type CallbackFunc func(response gorequest.Response, v interface{}, body []byte, errs []error)
 
+// metricStatusError is the status label of the requests without response, e.g. dial failure or timeout
+const metricStatusError = "error"
+
 // NewMetricCallback will record the http request data into metrics
 func NewMetricCallback(system string, start time.Time) CallbackFunc {
 	return func(response gorequest.Response, v interface{}, body []byte, errs []error) {
-		duration := time.Since(start)
-
-		metric.ClientRequestDuration.With(prometheus.Labels{
-			"method":    response.Request.Method,
-			"path":      response.Request.URL.Path,
-			"status":    strconv.Itoa(response.StatusCode),
-			"component": system,
-		}).Observe(float64(duration / time.Millisecond))
+		if response == nil {
+			return
+		}
+		recordRequestMetric(system, response.Request, response.StatusCode, start)
 	}
 }
+
+// recordRequestMetric will record the http request into metrics, the duration includes all the attempts
+func recordRequestMetric(system string, req *http.Request, statusCode int, start time.Time) {
+	duration := time.Since(start)
+
+	metric.ClientRequestDuration.With(prometheus.Labels{
+		"method":    req.Method,
+		"path":      req.URL.Path,
+		"status":    strconv.Itoa(statusCode),
+		"component": system,
+	}).Observe(float64(duration / time.Millisecond))
+}
+
+// recordAttemptMetric will record one attempt of the http request into metrics,
+// the status is `error` if there is no response
+func recordAttemptMetric(system string, req *http.Request, resp *http.Response, attempt int, start time.Time) {
+	duration := time.Since(start)
+
+	status := metricStatusError
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	metric.ClientRequestAttemptDuration.With(prometheus.Labels{
+		"method":    req.Method,
+		"path":      req.URL.Path,
+		"status":    status,
+		"component": system,
+		"attempt":   strconv.Itoa(attempt),
+	}).Observe(float64(duration / time.Millisecond))
+}
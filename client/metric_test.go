@@ -29,6 +29,8 @@ var _ = Describe("Metric", func() {
 
 		assert.NotNil(GinkgoT(), f)
 
+		assert.NotPanics(GinkgoT(), func() { f(nil, nil, nil, nil) })
+
 	})
 
 })
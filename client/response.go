@@ -0,0 +1,110 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+)
+
+// the typed data of the iam backend responses, decoded from IAMBackendBaseResponse.Data directly
+
+// Token is the data of the system token api
+type Token struct {
+	Token string `json:"token"`
+}
+
+// ApplyURL is the data of the application api
+type ApplyURL struct {
+	URL string `json:"url"`
+}
+
+// PolicyQueryResult is the data of the policy query api, the expression of the granted policies;
+// the expression is empty(OP is "") if no policy
+type PolicyQueryResult struct {
+	expression.ExprCell
+}
+
+// ActionPolicyAction is the action of ActionPolicy
+type ActionPolicyAction struct {
+	ID string `json:"id"`
+}
+
+// ActionPolicy is the item of the policy query by actions api
+type ActionPolicy struct {
+	Action    ActionPolicyAction  `json:"action"`
+	Condition expression.ExprCell `json:"condition"`
+}
+
+// ModelItem is the system, resource type, action or instance selection in SystemModel
+type ModelItem struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	NameEn string `json:"name_en"`
+}
+
+// SystemModel is the data of the model query api, only the common fields of each model are decoded,
+// use ModelQuery for the full data
+type SystemModel struct {
+	BaseInfo           ModelItem   `json:"base_info"`
+	ResourceTypes      []ModelItem `json:"resource_types"`
+	Actions            []ModelItem `json:"actions"`
+	InstanceSelections []ModelItem `json:"instance_selections"`
+}
+
+// PolicyAuthResult is the data of the policy auth api
+type PolicyAuthResult struct {
+	Allowed bool `json:"allowed"`
+}
+
+// PolicySubject is the subject of Policy, with the display name
+type PolicySubject struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Policy is the data of the policy get api, and the item of the policy list api(the Action is empty)
+type Policy struct {
+	ID         int64               `json:"id"`
+	Version    string              `json:"version"`
+	Action     ActionPolicyAction  `json:"action"`
+	Subject    PolicySubject       `json:"subject"`
+	Expression expression.ExprCell `json:"expression"`
+	// ExpiredAt is the unix timestamp(seconds) of the expiry
+	ExpiredAt int64 `json:"expired_at"`
+}
+
+// PolicyListMetadata is the metadata of PolicyListResult
+type PolicyListMetadata struct {
+	Action ActionPolicyAction `json:"action"`
+	// Timestamp is the unix timestamp(seconds) the policies are listed at, pass it to the next pages
+	Timestamp int64 `json:"timestamp"`
+}
+
+// PolicyListResult is the data of the policy list api
+type PolicyListResult struct {
+	Metadata PolicyListMetadata `json:"metadata"`
+	Count    int64              `json:"count"`
+	Results  []Policy           `json:"results"`
+}
+
+// PolicySubjectItem is the item of the policy subjects api
+type PolicySubjectItem struct {
+	ID      int64         `json:"id"`
+	Subject PolicySubject `json:"subject"`
+}
+
+// AuthorizationPolicy is the policy granted or revoked by the authorization apis
+type AuthorizationPolicy struct {
+	Action   ActionPolicyAction `json:"action"`
+	PolicyID int64              `json:"policy_id"`
+}
@@ -0,0 +1,116 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/TencentBlueKing/iam-go-sdk/util"
+)
+
+// RetryPolicy is the retry policy of the iam backend calls without side effect,
+// e.g. policy query, get token and model query; the model-mutating calls will never be retried
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts, including the first one; no retry if <= 1
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry, will be doubled for each retry
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the backoff
+	MaxBackoff time.Duration
+	// RetryableStatusCodes is the http status codes should be retried
+	RetryableStatusCodes []int
+	// IsRetryableError checks if the error without http response(e.g. network error) should be retried,
+	// default is IsRetryableNetworkError
+	IsRetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy returns the recommended retry policy:
+// max 3 attempts, backoff from 100ms to 1s, retry on 429/502/503/504 and the retryable network errors
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     1 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		IsRetryableError: IsRetryableNetworkError,
+	}
+}
+
+// WithRetryPolicy set the retry policy of the iam backend calls without side effect
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *iamBackendClient) {
+		c.retryPolicy = &policy
+	}
+}
+
+// IsRetryableNetworkError checks if the network error should be retried,
+// connection refused/reset and unexpected EOF will be retried, while the canceled or timeout call will not
+func IsRetryableNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isRetryable checks if the call should be retried by the status code(0 means no response) and the error
+func (p *RetryPolicy) isRetryable(statusCode int, err error) bool {
+	if p == nil {
+		return false
+	}
+
+	if statusCode != 0 {
+		return util.Contains(p.RetryableStatusCodes, statusCode)
+	}
+
+	if p.IsRetryableError == nil {
+		return IsRetryableNetworkError(err)
+	}
+	return p.IsRetryableError(err)
+}
+
+// backoff returns the exponential backoff with jitter before the next attempt, in [d/2, d]
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	half := int64(d / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
@@ -12,6 +12,8 @@
 package client
 
 import (
+	"net"
+	"net/http"
 	"time"
 )
 
@@ -19,6 +21,24 @@ const (
 	defaultTimeout = 5 * time.Second
 )
 
+// defaultHTTPClient is shared by all the iam backend clients without WithHTTPClient/WithTransport,
+// keep-alive is enabled, so the connections to iam backend can be reused
+var defaultHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+}
+
 // responseBody is the interface for fail http response log
 type responseBody interface {
 	Error() error
@@ -0,0 +1,460 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression/eval"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// CompiledExpr is the compiled expression, evals exactly the same as the ExprCell,
+// but the operator and value checks are done once in Compile, and `in`/`not_in` are evaluated by hash sets;
+// the Eval does not allocate for the attributes of string, number, bool, []interface{} and []string
+type CompiledExpr struct {
+	eval evalFunc
+}
+
+// Eval will evaluate the compiled expression with ObjectSet, return true or false
+func (c CompiledExpr) Eval(data ObjectSetInterface) bool {
+	if c.eval == nil {
+		return false
+	}
+	return c.eval(data)
+}
+
+type evalFunc func(data ObjectSetInterface) bool
+
+// matcher matches one value of the object, matchString is the same as match but without boxing the string
+type matcher struct {
+	match       func(v interface{}) bool
+	matchString func(s string) bool
+}
+
+func alwaysFalse(data ObjectSetInterface) bool {
+	return false
+}
+
+func alwaysTrue(data ObjectSetInterface) bool {
+	return true
+}
+
+// Compile will compile the expression for evaluating many times, e.g. BatchIsAllowed;
+// return error if the operator is not supported, the empty expression(no policy) is compiled to always false
+func Compile(expr ExprCell) (CompiledExpr, error) {
+	if expr.OP == "" {
+		return CompiledExpr{eval: alwaysFalse}, nil
+	}
+
+	f, err := compileExpr(expr)
+	if err != nil {
+		return CompiledExpr{}, err
+	}
+	return CompiledExpr{eval: f}, nil
+}
+
+func compileExpr(e ExprCell) (evalFunc, error) {
+	switch e.OP {
+	case operator.AND, operator.OR:
+		children := make([]evalFunc, 0, len(e.Content))
+		for _, c := range e.Content {
+			f, err := compileExpr(c)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, f)
+		}
+
+		if e.OP == operator.AND {
+			return func(data ObjectSetInterface) bool {
+				for _, f := range children {
+					if !f(data) {
+						return false
+					}
+				}
+				return true
+			}, nil
+		}
+		return func(data ObjectSetInterface) bool {
+			for _, f := range children {
+				if f(data) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case operator.Any:
+		return alwaysTrue, nil
+	default:
+		return compileBinaryOperator(e.OP, e.Field, e.Value)
+	}
+}
+
+// compileBinaryOperator is the compiled version of evalBinaryOperator
+func compileBinaryOperator(op operator.OP, field string, policyValue interface{}) (evalFunc, error) {
+	// support _bk_iam_path_, starts with from `/a,1/b,*/` to `/a,1/b,`
+	if op == operator.StartsWith && strings.HasSuffix(field, KeywordBKIAMPathFieldSuffix) {
+		if v, ok := policyValue.(string); ok && strings.HasSuffix(v, ",*/") {
+			policyValue = strings.TrimSuffix(v, "*/")
+		}
+	}
+
+	switch op {
+	case operator.Eq, operator.NotEq:
+		if isValueTypeArray(policyValue) {
+			return alwaysFalse, nil
+		}
+		m := equalMatcher(policyValue)
+		if op == operator.Eq {
+			return anyOf(field, m), nil
+		}
+		return allOf(field, negate(m)), nil
+	case operator.Lt, operator.Lte, operator.Gt, operator.Gte:
+		if isValueTypeArray(policyValue) {
+			return alwaysFalse, nil
+		}
+		return anyOf(field, compareMatcher(op, policyValue)), nil
+	case operator.StartsWith, operator.EndsWith, operator.StringContains,
+		operator.NotStartsWith, operator.NotEndsWith:
+		if isValueTypeArray(policyValue) {
+			return alwaysFalse, nil
+		}
+		m := stringMatcher(op, policyValue)
+		if op == operator.NotStartsWith || op == operator.NotEndsWith {
+			return allOf(field, m), nil
+		}
+		return anyOf(field, m), nil
+	case operator.In, operator.NotIn:
+		if !isValueTypeArray(policyValue) {
+			return alwaysFalse, nil
+		}
+		m := inMatcher(policyValue)
+		if op == operator.In {
+			return anyOf(field, m), nil
+		}
+		return allOf(field, negate(m)), nil
+	case operator.Contains, operator.NotContains:
+		if isValueTypeArray(policyValue) {
+			return alwaysFalse, nil
+		}
+		m := equalMatcher(policyValue)
+		negative := op == operator.NotContains
+		return func(data ObjectSetInterface) bool {
+			objectValue := data.GetAttribute(field)
+			// the object value should be an array
+			if !isValueTypeArray(objectValue) {
+				return false
+			}
+			return anyValue(objectValue, m) != negative
+		}, nil
+	default:
+		return nil, fmt.Errorf("the operator `%s` of field `%s` is not supported", op, field)
+	}
+}
+
+// anyOf is the compiled version of evalPositive, true if the object value or any of the array matches
+func anyOf(field string, m matcher) evalFunc {
+	return func(data ObjectSetInterface) bool {
+		objectValue := data.GetAttribute(field)
+		if isValueTypeArray(objectValue) {
+			return anyValue(objectValue, m)
+		}
+		return m.match(objectValue)
+	}
+}
+
+// allOf is the compiled version of evalNegative, true if the object value or all of the array match
+func allOf(field string, m matcher) evalFunc {
+	return func(data ObjectSetInterface) bool {
+		objectValue := data.GetAttribute(field)
+		if isValueTypeArray(objectValue) {
+			return allValues(objectValue, m)
+		}
+		return m.match(objectValue)
+	}
+}
+
+func anyValue(array interface{}, m matcher) bool {
+	switch values := array.(type) {
+	case []interface{}:
+		for _, v := range values {
+			if m.match(v) {
+				return true
+			}
+		}
+	case []string:
+		for _, s := range values {
+			if m.matchString(s) {
+				return true
+			}
+		}
+	default:
+		listValue := reflect.ValueOf(array)
+		for i := 0; i < listValue.Len(); i++ {
+			if m.match(listValue.Index(i).Interface()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allValues(array interface{}, m matcher) bool {
+	switch values := array.(type) {
+	case []interface{}:
+		for _, v := range values {
+			if !m.match(v) {
+				return false
+			}
+		}
+	case []string:
+		for _, s := range values {
+			if !m.matchString(s) {
+				return false
+			}
+		}
+	default:
+		listValue := reflect.ValueOf(array)
+		for i := 0; i < listValue.Len(); i++ {
+			if !m.match(listValue.Index(i).Interface()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func negate(m matcher) matcher {
+	return matcher{
+		match:       func(v interface{}) bool { return !m.match(v) },
+		matchString: func(s string) bool { return !m.matchString(s) },
+	}
+}
+
+// isScalarKind will check if the value is comparable by `==` same as reflect.DeepEqual
+func isScalarKind(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	kind := reflect.TypeOf(v).Kind()
+	return (kind >= reflect.Bool && kind <= reflect.Complex128) || kind == reflect.String
+}
+
+// equalMatcher is the compiled version of eval.Equal(objectValue, policyValue)
+func equalMatcher(policyValue interface{}) matcher {
+	switch {
+	case policyValue == nil:
+		return matcher{
+			match:       func(v interface{}) bool { return v == nil },
+			matchString: func(s string) bool { return false },
+		}
+	case isScalarKind(policyValue):
+		// the scalar is comparable, the `==` of interfaces is false if the types are different
+		str, isString := policyValue.(string)
+		return matcher{
+			match:       func(v interface{}) bool { return v == policyValue },
+			matchString: func(s string) bool { return isString && s == str },
+		}
+	default:
+		// the type of policy value is not string, never equals to a string
+		return matcher{
+			match:       func(v interface{}) bool { return eval.Equal(v, policyValue) },
+			matchString: func(s string) bool { return false },
+		}
+	}
+}
+
+// inMatcher is the compiled version of eval.In(objectValue, policyValue), the policyValue is an array
+func inMatcher(policyValue interface{}) matcher {
+	listValue := reflect.ValueOf(policyValue)
+	// eval.In compare the strings by value if the kind of list element is string, e.g. []string,
+	// otherwise compare by reflect.DeepEqual, only the same type of scalar can be equal
+	stringList := listValue.Type().Elem().Kind() == reflect.String
+
+	stringSet := make(map[string]struct{}, listValue.Len())
+	scalarSet := map[interface{}]struct{}{}
+	for i := 0; i < listValue.Len(); i++ {
+		if stringList {
+			stringSet[listValue.Index(i).String()] = struct{}{}
+			continue
+		}
+
+		item := listValue.Index(i).Interface()
+		if s, ok := item.(string); ok {
+			stringSet[s] = struct{}{}
+		} else if isScalarKind(item) {
+			scalarSet[item] = struct{}{}
+		}
+	}
+
+	return matcher{
+		match: func(v interface{}) bool {
+			if s, ok := v.(string); ok {
+				_, found := stringSet[s]
+				return found
+			}
+			if isScalarKind(v) {
+				if stringList && reflect.TypeOf(v).Kind() == reflect.String {
+					// the named string type, e.g. json.Number
+					return eval.In(v, policyValue)
+				}
+				_, found := scalarSet[v]
+				return found
+			}
+			return eval.In(v, policyValue)
+		},
+		matchString: func(s string) bool {
+			_, found := stringSet[s]
+			return found
+		},
+	}
+}
+
+// stringMatcher is the compiled version of eval.StartsWith/EndsWith/StringContains/NotStartsWith/NotEndsWith
+func stringMatcher(op operator.OP, policyValue interface{}) matcher {
+	str, ok := policyValue.(string)
+	if !ok {
+		// both should be string
+		return matcher{
+			match:       func(v interface{}) bool { return false },
+			matchString: func(s string) bool { return false },
+		}
+	}
+
+	var f func(s string) bool
+	switch op {
+	case operator.StartsWith:
+		f = func(s string) bool { return strings.HasPrefix(s, str) }
+	case operator.NotStartsWith:
+		f = func(s string) bool { return !strings.HasPrefix(s, str) }
+	case operator.EndsWith:
+		f = func(s string) bool { return strings.HasSuffix(s, str) }
+	case operator.NotEndsWith:
+		f = func(s string) bool { return !strings.HasSuffix(s, str) }
+	default:
+		f = func(s string) bool { return strings.Contains(s, str) }
+	}
+
+	return matcher{
+		match: func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && f(s)
+		},
+		matchString: f,
+	}
+}
+
+// compareMatcher is the compiled version of eval.Less/LessOrEqual/Greater/GreaterOrEqual,
+// the json.Number policy value is cast to int64/float64 once
+func compareMatcher(op operator.OP, policyValue interface{}) matcher {
+	var fallback EvalFunc
+	var hit func(result int) bool
+	switch op {
+	case operator.Lt:
+		fallback, hit = eval.Less, func(result int) bool { return result < 0 }
+	case operator.Lte:
+		fallback, hit = eval.LessOrEqual, func(result int) bool { return result <= 0 }
+	case operator.Gt:
+		fallback, hit = eval.Greater, func(result int) bool { return result > 0 }
+	default:
+		fallback, hit = eval.GreaterOrEqual, func(result int) bool { return result >= 0 }
+	}
+
+	if n, ok := policyValue.(json.Number); ok {
+		if strings.IndexByte(n.String(), '.') != -1 {
+			if f, err := n.Float64(); err == nil {
+				policyValue = f
+			}
+		} else if i, err := n.Int64(); err == nil {
+			policyValue = i
+		}
+	}
+
+	str, isString := policyValue.(string)
+	policyFloat, policyInt, isFloat, isNumber := toNumber(policyValue)
+	isStringKind := policyValue != nil && reflect.TypeOf(policyValue).Kind() == reflect.String
+
+	compareNumber := func(f float64, i int64, objectIsFloat bool) bool {
+		if objectIsFloat || isFloat {
+			// NaN is not comparable
+			switch {
+			case f > policyFloat:
+				return hit(1)
+			case f == policyFloat:
+				return hit(0)
+			case f < policyFloat:
+				return hit(-1)
+			default:
+				return false
+			}
+		}
+
+		switch {
+		case i > policyInt:
+			return hit(1)
+		case i == policyInt:
+			return hit(0)
+		default:
+			return hit(-1)
+		}
+	}
+
+	return matcher{
+		match: func(v interface{}) bool {
+			if s, ok := v.(string); ok && isString {
+				return hit(strings.Compare(s, str))
+			}
+			if isNumber {
+				if f, i, objectIsFloat, ok := toNumber(v); ok {
+					return compareNumber(f, i, objectIsFloat)
+				}
+			}
+			return fallback(v, policyValue)
+		},
+		matchString: func(s string) bool {
+			if isString {
+				return hit(strings.Compare(s, str))
+			}
+			if !isStringKind {
+				// the kinds are different
+				return false
+			}
+			return fallback(s, policyValue)
+		},
+	}
+}
+
+// toNumber will convert the signed int and float to float64 and int64, same as eval.toFloat64/toInt64
+func toNumber(v interface{}) (f float64, i int64, isFloat bool, ok bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, 0, true, true
+	case float32:
+		return float64(n), 0, true, true
+	case int:
+		return float64(n), int64(n), false, true
+	case int64:
+		return float64(n), n, false, true
+	case int32:
+		return float64(n), int64(n), false, true
+	case int16:
+		return float64(n), int64(n), false, true
+	case int8:
+		return float64(n), int64(n), false, true
+	default:
+		return 0, 0, false, false
+	}
+}
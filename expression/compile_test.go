@@ -0,0 +1,214 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+type namedString string
+
+var allOperators = []operator.OP{
+	operator.Eq, operator.NotEq, operator.In, operator.NotIn,
+	operator.Contains, operator.NotContains,
+	operator.StartsWith, operator.NotStartsWith, operator.EndsWith, operator.NotEndsWith,
+	operator.StringContains,
+	operator.Lt, operator.Lte, operator.Gt, operator.Gte,
+	operator.Any,
+}
+
+// the values of all kinds, for both the policy and the object
+var compileTestValues = []interface{}{
+	nil, "a", "ab", "b", "", namedString("a"), json.Number("1"), json.Number("1.5"),
+	1, 2, int32(1), int64(2), uint(1), float64(1), 1.5, float32(2), math.NaN(), true,
+	[]interface{}{}, []interface{}{"a", 1}, []interface{}{float64(1), "b", nil, namedString("a")},
+	[]string{"a", "b"}, []string{}, []int{1, 2}, []byte("a"), []namedString{"a"},
+	map[string]interface{}{"a": 1},
+}
+
+func newBenchmarkData() (expression.ExprCell, []expression.ObjectSetInterface) {
+	ids := make([]interface{}, 0, 100)
+	for i := 0; i < 100; i++ {
+		ids = append(ids, fmt.Sprintf("%d", i*2))
+	}
+
+	expr := expression.ExprCell{
+		OP: operator.OR,
+		Content: []expression.ExprCell{
+			{OP: operator.In, Field: "host.id", Value: ids},
+			{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"},
+			{
+				OP: operator.AND,
+				Content: []expression.ExprCell{
+					{OP: operator.Eq, Field: "host.owner", Value: "admin"},
+					{OP: operator.Gte, Field: "host.level", Value: float64(3)},
+				},
+			},
+		},
+	}
+
+	objSets := make([]expression.ObjectSetInterface, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		o := expression.NewObjectSet()
+		o.Set("host", map[string]interface{}{
+			"id":            fmt.Sprintf("%d", i),
+			"_bk_iam_path_": []interface{}{fmt.Sprintf("/biz,%d/set,%d/", i%3, i)},
+			"owner":         []string{"user", "admin"},
+			"level":         i % 5,
+		})
+		objSets = append(objSets, o)
+	}
+	return expr, objSets
+}
+
+var _ = Describe("Compile", func() {
+	It("same as Eval for all operators and values", func() {
+		for _, op := range allOperators {
+			for _, policyValue := range compileTestValues {
+				e := expression.ExprCell{OP: op, Field: "obj.attr", Value: policyValue}
+				compiled, err := expression.Compile(e)
+				assert.NoError(GinkgoT(), err)
+
+				for _, objectValue := range compileTestValues {
+					o := expression.NewObjectSet()
+					o.Set("obj", map[string]interface{}{"attr": objectValue})
+
+					assert.Equal(GinkgoT(), e.Eval(o), compiled.Eval(o),
+						"(%#v %s %#v)", objectValue, op, policyValue)
+				}
+
+				// the object not exists
+				assert.Equal(GinkgoT(), e.Eval(expression.NewObjectSet()), compiled.Eval(expression.NewObjectSet()))
+			}
+		}
+	})
+
+	It("_bk_iam_path_", func() {
+		e := expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"}
+		compiled, err := expression.Compile(e)
+		assert.NoError(GinkgoT(), err)
+
+		for _, path := range []interface{}{"/biz,1/set,2/", "/biz,1/", []string{"/biz,2/", "/biz,1/set,3/"}} {
+			o := expression.NewObjectSet()
+			o.Set("host", map[string]interface{}{"_bk_iam_path_": path})
+			assert.Equal(GinkgoT(), e.Eval(o), compiled.Eval(o))
+		}
+	})
+
+	It("same as Eval for random expressions", func() {
+		r := rand.New(rand.NewSource(1))
+		fields := []string{"host.id", "host.tags", "biz.id"}
+
+		var randExpr func(depth int) expression.ExprCell
+		randExpr = func(depth int) expression.ExprCell {
+			if depth > 0 && r.Intn(3) > 0 {
+				op := operator.AND
+				if r.Intn(2) == 0 {
+					op = operator.OR
+				}
+				content := []expression.ExprCell{}
+				for n := r.Intn(4); n > 0; n-- {
+					content = append(content, randExpr(depth-1))
+				}
+				return expression.ExprCell{OP: op, Content: content}
+			}
+			return expression.ExprCell{
+				OP:    allOperators[r.Intn(len(allOperators))],
+				Field: fields[r.Intn(len(fields))],
+				Value: compileTestValues[r.Intn(len(compileTestValues))],
+			}
+		}
+
+		for n := 0; n < 1000; n++ {
+			e := randExpr(3)
+			compiled, err := expression.Compile(e)
+			assert.NoError(GinkgoT(), err)
+
+			for m := 0; m < 5; m++ {
+				o := expression.NewObjectSet()
+				o.Set("host", map[string]interface{}{
+					"id":   compileTestValues[r.Intn(len(compileTestValues))],
+					"tags": compileTestValues[r.Intn(len(compileTestValues))],
+				})
+				if r.Intn(2) == 0 {
+					o.Set("biz", map[string]interface{}{"id": compileTestValues[r.Intn(len(compileTestValues))]})
+				}
+
+				assert.Equal(GinkgoT(), e.Eval(o), compiled.Eval(o), e.String())
+			}
+		}
+	})
+
+	It("no allocation", func() {
+		expr, objSets := newBenchmarkData()
+		compiled, err := expression.Compile(expr)
+		assert.NoError(GinkgoT(), err)
+
+		allocs := testing.AllocsPerRun(10, func() {
+			for _, o := range objSets {
+				compiled.Eval(o)
+			}
+		})
+		assert.Zero(GinkgoT(), allocs)
+	})
+
+	It("empty expression", func() {
+		compiled, err := expression.Compile(expression.ExprCell{})
+
+		assert.NoError(GinkgoT(), err)
+		assert.False(GinkgoT(), compiled.Eval(expression.NewObjectSet()))
+		assert.False(GinkgoT(), expression.CompiledExpr{}.Eval(expression.NewObjectSet()))
+	})
+
+	It("unsupported operator", func() {
+		_, err := expression.Compile(expression.ExprCell{
+			OP:      operator.OR,
+			Content: []expression.ExprCell{{OP: "regex", Field: "host.id", Value: "1"}},
+		})
+
+		assert.ErrorContains(GinkgoT(), err, "regex")
+	})
+})
+
+func BenchmarkExprCellEval(b *testing.B) {
+	expr, objSets := newBenchmarkData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expr.Eval(objSets[i%len(objSets)])
+	}
+}
+
+func BenchmarkCompiledExprEval(b *testing.B) {
+	expr, objSets := newBenchmarkData()
+	compiled, err := expression.Compile(expr)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		compiled.Eval(objSets[i%len(objSets)])
+	}
+}
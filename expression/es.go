@@ -0,0 +1,227 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var esRangeOperators = map[operator.OP]string{
+	operator.Lt:  "lt",
+	operator.Lte: "lte",
+	operator.Gt:  "gt",
+	operator.Gte: "gte",
+}
+
+// ToESQuery will translate the expression into an Elasticsearch/OpenSearch query DSL,
+// the fieldMapping is from the field(`type.attr`, e.g. `host.id`, `host._bk_iam_path_`) to the document field
+//
+// the fields of a document are multi-valued, the result is the same as Eval with an array attribute:
+// - eq/in/starts_with/ends_with/string_contains/lt/lte/gt/gte, hit if any value hit
+// - not_eq/not_in, hit if all values hit, the missing field is hit
+// - not_starts_with/not_ends_with/contains/not_contains, the missing field is not hit
+// - eq nil hit the missing field, not_eq nil hit the existing field, es ignore the null values, so contains nil is never hit
+// NOTE: es can not tell a single value from an array with one value, so contains/not_contains hit the single value field
+// NOTE: es can not tell an empty array from the missing field, so not_starts_with/not_ends_with/not_contains
+// is not hit for the empty array attribute, while Eval is true
+func ToESQuery(expr ExprCell, fieldMapping map[string]string) (map[string]interface{}, error) {
+	switch expr.OP {
+	case operator.AND, operator.OR:
+		if len(expr.Content) == 0 {
+			if expr.OP == operator.AND {
+				return esMatchAll(), nil
+			}
+			return esMatchNone(), nil
+		}
+
+		queries := make([]interface{}, 0, len(expr.Content))
+		for _, c := range expr.Content {
+			query, err := ToESQuery(c, fieldMapping)
+			if err != nil {
+				return nil, err
+			}
+			queries = append(queries, query)
+		}
+
+		if expr.OP == operator.AND {
+			return esBool("must", queries), nil
+		}
+		query := esBool("should", queries)
+		query["bool"].(map[string]interface{})["minimum_should_match"] = 1
+		return query, nil
+	case operator.Any:
+		return esMatchAll(), nil
+	case "":
+		// no policy, same as Eval
+		return esMatchNone(), nil
+	default:
+		return esBinaryQuery(expr.OP, expr.Field, expr.Value, fieldMapping)
+	}
+}
+
+func esBinaryQuery(
+	op operator.OP,
+	field string,
+	value interface{},
+	fieldMapping map[string]string,
+) (map[string]interface{}, error) {
+	name, ok := fieldMapping[field]
+	if !ok || name == "" {
+		return nil, fmt.Errorf("the field `%s` has no es field in the mapping: %w", field, ErrUnmappableField)
+	}
+
+	// support _bk_iam_path_, starts with from `/a,1/b,*/` to `/a,1/b,`
+	if op == operator.StartsWith && strings.HasSuffix(field, KeywordBKIAMPathFieldSuffix) {
+		if v, ok := value.(string); ok && strings.HasSuffix(v, ",*/") {
+			value = strings.TrimSuffix(v, "*/")
+		}
+	}
+
+	switch op {
+	case operator.Eq, operator.NotEq, operator.Contains, operator.NotContains:
+		if isValueTypeArray(value) {
+			return esMatchNone(), nil
+		}
+
+		// es reject the term query with null value
+		if value == nil {
+			return esNilQuery(op, name), nil
+		}
+
+		query := map[string]interface{}{"term": map[string]interface{}{name: value}}
+		switch op {
+		case operator.NotEq:
+			return esMustNot(query), nil
+		case operator.NotContains:
+			return esExistsAndMustNot(name, query), nil
+		}
+		return query, nil
+	case operator.In, operator.NotIn:
+		if !isValueTypeArray(value) {
+			return esMatchNone(), nil
+		}
+
+		listValue := reflect.ValueOf(value)
+		if listValue.Len() == 0 {
+			if op == operator.In {
+				return esMatchNone(), nil
+			}
+			return esMatchAll(), nil
+		}
+
+		values := make([]interface{}, 0, listValue.Len())
+		for i := 0; i < listValue.Len(); i++ {
+			values = append(values, listValue.Index(i).Interface())
+		}
+
+		query := map[string]interface{}{"terms": map[string]interface{}{name: values}}
+		if op == operator.NotIn {
+			return esMustNot(query), nil
+		}
+		return query, nil
+	case operator.StartsWith, operator.NotStartsWith,
+		operator.EndsWith, operator.NotEndsWith,
+		operator.StringContains:
+		v, ok := value.(string)
+		if !ok {
+			return esMatchNone(), nil
+		}
+
+		var query map[string]interface{}
+		switch op {
+		case operator.StartsWith, operator.NotStartsWith:
+			query = map[string]interface{}{"prefix": map[string]interface{}{name: v}}
+		case operator.EndsWith, operator.NotEndsWith:
+			query = esWildcard(name, "*"+escapeWildcard(v))
+		default:
+			query = esWildcard(name, "*"+escapeWildcard(v)+"*")
+		}
+
+		if op == operator.NotStartsWith || op == operator.NotEndsWith {
+			return esExistsAndMustNot(name, query), nil
+		}
+		return query, nil
+	case operator.Lt, operator.Lte, operator.Gt, operator.Gte:
+		if isValueTypeArray(value) {
+			return esMatchNone(), nil
+		}
+
+		return map[string]interface{}{
+			"range": map[string]interface{}{
+				name: map[string]interface{}{esRangeOperators[op]: value},
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("the operator `%s` of field `%s` is not supported", op, field)
+	}
+}
+
+func esMatchAll() map[string]interface{} {
+	return map[string]interface{}{"match_all": map[string]interface{}{}}
+}
+
+func esMatchNone() map[string]interface{} {
+	return map[string]interface{}{"match_none": map[string]interface{}{}}
+}
+
+func esBool(occur string, queries []interface{}) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{occur: queries}}
+}
+
+func esMustNot(query map[string]interface{}) map[string]interface{} {
+	return esBool("must_not", []interface{}{query})
+}
+
+// esNilQuery translate the eq/not_eq/contains/not_contains with nil value, the missing field equals to nil
+func esNilQuery(op operator.OP, name string) map[string]interface{} {
+	exists := map[string]interface{}{"exists": map[string]interface{}{"field": name}}
+	switch op {
+	case operator.Eq:
+		return esMustNot(exists)
+	case operator.Contains:
+		return esMatchNone()
+	default:
+		return exists
+	}
+}
+
+// esExistsAndMustNot hit the documents which have the field and not hit the query,
+// the negative string operators and not_contains are false for the missing attribute,
+// the empty array is indexed as the missing field, so it is not hit too
+func esExistsAndMustNot(name string, query map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":     []interface{}{map[string]interface{}{"exists": map[string]interface{}{"field": name}}},
+			"must_not": []interface{}{query},
+		},
+	}
+}
+
+func esWildcard(name, pattern string) map[string]interface{} {
+	return map[string]interface{}{"wildcard": map[string]interface{}{name: map[string]interface{}{"value": pattern}}}
+}
+
+// escapeWildcard will escape the `*`, `?` and `\` of wildcard pattern
+func escapeWildcard(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '*' || r == '?' || r == '\\' {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
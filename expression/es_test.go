@@ -0,0 +1,168 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("ESQuery", func() {
+	fieldMapping := map[string]string{
+		"host.id":            "id",
+		"host.name":          "name.keyword",
+		"host.level":         "level",
+		"host.tags":          "tags",
+		"host._bk_iam_path_": "iam_path",
+	}
+
+	// compare with the json, the query will be sent as json
+	assertQuery := func(expr expression.ExprCell, expected string) {
+		query, err := expression.ToESQuery(expr, fieldMapping)
+		assert.NoError(GinkgoT(), err, expr.String())
+
+		data, err := json.Marshal(query)
+		assert.NoError(GinkgoT(), err)
+		assert.JSONEq(GinkgoT(), expected, string(data), expr.String())
+	}
+
+	It("positive operators", func() {
+		assertQuery(expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: "1"},
+			`{"term": {"id": "1"}}`)
+		assertQuery(expression.ExprCell{OP: operator.In, Field: "host.id", Value: []string{"1", "2"}},
+			`{"terms": {"id": ["1", "2"]}}`)
+		assertQuery(expression.ExprCell{OP: operator.Contains, Field: "host.tags", Value: "db"},
+			`{"term": {"tags": "db"}}`)
+		assertQuery(expression.ExprCell{OP: operator.StartsWith, Field: "host.name", Value: "web*"},
+			`{"prefix": {"name.keyword": "web*"}}`)
+		assertQuery(expression.ExprCell{OP: operator.EndsWith, Field: "host.name", Value: "-01?"},
+			`{"wildcard": {"name.keyword": {"value": "*-01\\?"}}}`)
+		assertQuery(expression.ExprCell{OP: operator.StringContains, Field: "host.name", Value: `a*b\`},
+			`{"wildcard": {"name.keyword": {"value": "*a\\*b\\\\*"}}}`)
+		assertQuery(expression.ExprCell{OP: operator.Lt, Field: "host.level", Value: 1},
+			`{"range": {"level": {"lt": 1}}}`)
+		assertQuery(expression.ExprCell{OP: operator.Lte, Field: "host.level", Value: 1},
+			`{"range": {"level": {"lte": 1}}}`)
+		assertQuery(expression.ExprCell{OP: operator.Gt, Field: "host.level", Value: 1},
+			`{"range": {"level": {"gt": 1}}}`)
+		assertQuery(expression.ExprCell{OP: operator.Gte, Field: "host.level", Value: 1},
+			`{"range": {"level": {"gte": 1}}}`)
+		assertQuery(expression.ExprCell{OP: operator.Any, Field: "host.id", Value: []interface{}{}},
+			`{"match_all": {}}`)
+	})
+
+	It("negative operators", func() {
+		// the missing field is hit
+		assertQuery(expression.ExprCell{OP: operator.NotEq, Field: "host.id", Value: "1"},
+			`{"bool": {"must_not": [{"term": {"id": "1"}}]}}`)
+		assertQuery(expression.ExprCell{OP: operator.NotIn, Field: "host.id", Value: []interface{}{"1", "2"}},
+			`{"bool": {"must_not": [{"terms": {"id": ["1", "2"]}}]}}`)
+
+		// the missing field is not hit
+		assertQuery(expression.ExprCell{OP: operator.NotContains, Field: "host.tags", Value: "db"},
+			`{"bool": {"must": [{"exists": {"field": "tags"}}], "must_not": [{"term": {"tags": "db"}}]}}`)
+		assertQuery(expression.ExprCell{OP: operator.NotStartsWith, Field: "host.name", Value: "web"},
+			`{"bool": {"must": [{"exists": {"field": "name.keyword"}}], "must_not": [{"prefix": {"name.keyword": "web"}}]}}`)
+		assertQuery(expression.ExprCell{OP: operator.NotEndsWith, Field: "host.name", Value: "01"},
+			`{"bool": {"must": [{"exists": {"field": "name.keyword"}}], "must_not": [{"wildcard": {"name.keyword": {"value": "*01"}}}]}}`)
+	})
+
+	It("nil value", func() {
+		// the missing field equals to nil, es reject the term query with null value
+		assertQuery(expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: nil},
+			`{"bool": {"must_not": [{"exists": {"field": "id"}}]}}`)
+		assertQuery(expression.ExprCell{OP: operator.NotEq, Field: "host.id", Value: nil},
+			`{"exists": {"field": "id"}}`)
+		assertQuery(expression.ExprCell{OP: operator.Contains, Field: "host.tags", Value: nil},
+			`{"match_none": {}}`)
+		assertQuery(expression.ExprCell{OP: operator.NotContains, Field: "host.tags", Value: nil},
+			`{"exists": {"field": "tags"}}`)
+	})
+
+	It("the value type mismatch the operator", func() {
+		for _, expr := range []expression.ExprCell{
+			{OP: operator.Eq, Field: "host.id", Value: []interface{}{"1"}},
+			{OP: operator.NotEq, Field: "host.id", Value: []interface{}{"1"}},
+			{OP: operator.Contains, Field: "host.tags", Value: []interface{}{"db"}},
+			{OP: operator.NotContains, Field: "host.tags", Value: []interface{}{"db"}},
+			{OP: operator.In, Field: "host.id", Value: "1"},
+			{OP: operator.NotIn, Field: "host.id", Value: "1"},
+			{OP: operator.StartsWith, Field: "host.name", Value: 1},
+			{OP: operator.Gt, Field: "host.level", Value: []int{1}},
+			{OP: operator.In, Field: "host.id", Value: []interface{}{}},
+			{OP: operator.OR},
+			// no policy
+			{},
+		} {
+			assertQuery(expr, `{"match_none": {}}`)
+		}
+
+		assertQuery(expression.ExprCell{OP: operator.NotIn, Field: "host.id", Value: []interface{}{}}, `{"match_all": {}}`)
+		assertQuery(expression.ExprCell{OP: operator.AND}, `{"match_all": {}}`)
+	})
+
+	It("_bk_iam_path_", func() {
+		assertQuery(expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"},
+			`{"prefix": {"iam_path": "/biz,1/set,"}}`)
+		assertQuery(expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/"},
+			`{"prefix": {"iam_path": "/biz,1/"}}`)
+		// only starts_with is trimmed
+		assertQuery(expression.ExprCell{OP: operator.Eq, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"},
+			`{"term": {"iam_path": "/biz,1/set,*/"}}`)
+	})
+
+	It("AND and OR", func() {
+		assertQuery(expression.ExprCell{
+			OP: operator.OR,
+			Content: []expression.ExprCell{
+				{OP: operator.Eq, Field: "host.id", Value: "1"},
+				{
+					OP: operator.AND,
+					Content: []expression.ExprCell{
+						{OP: operator.Gte, Field: "host.level", Value: 2},
+						{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/"},
+					},
+				},
+			},
+		}, `{"bool": {"minimum_should_match": 1, "should": [
+			{"term": {"id": "1"}},
+			{"bool": {"must": [
+				{"range": {"level": {"gte": 2}}},
+				{"prefix": {"iam_path": "/biz,1/"}}
+			]}}
+		]}}`)
+	})
+
+	It("unmappable field", func() {
+		_, err := expression.ToESQuery(expression.ExprCell{
+			OP: operator.OR,
+			Content: []expression.ExprCell{
+				{OP: operator.Eq, Field: "host.owner", Value: "admin"},
+			},
+		}, fieldMapping)
+
+		assert.True(GinkgoT(), errors.Is(err, expression.ErrUnmappableField))
+		assert.Contains(GinkgoT(), err.Error(), "host.owner")
+	})
+
+	It("unsupported operator", func() {
+		_, err := expression.ToESQuery(expression.ExprCell{OP: "regex", Field: "host.id", Value: "1"}, fieldMapping)
+
+		assert.ErrorContains(GinkgoT(), err, "regex")
+	})
+})
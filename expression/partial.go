@@ -0,0 +1,112 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression
+
+import (
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// Decision is the result of PartialEval
+type Decision int
+
+const (
+	// Deny the expression is false whatever the unknown fields are
+	Deny Decision = iota
+	// Allow the expression is true whatever the unknown fields are
+	Allow
+	// Undecided the expression depends on the unknown fields, see the residual expression
+	Undecided
+)
+
+// String return the text of the decision
+func (d Decision) String() string {
+	switch d {
+	case Deny:
+		return "deny"
+	case Allow:
+		return "allow"
+	case Undecided:
+		return "undecided"
+	default:
+		return "unknown"
+	}
+}
+
+// PartialEval will evaluate the expression with the known fields of ObjectSet,
+// the field is known if the object has that attribute, the attribute with nil value is known too;
+// return Allow/Deny with an empty residual if decided(Deny for the empty expression, which means no policy),
+// or Undecided with the residual expression which only references the unknown fields,
+// the residual evals the same as the expression for any values of the unknown fields
+func (e *ExprCell) PartialEval(data ObjectSetInterface) (Decision, ExprCell) {
+	switch e.OP {
+	case operator.AND, operator.OR:
+		// AND: short-circuit on Deny, skip Allow; OR: short-circuit on Allow, skip Deny
+		shortCircuit, skip := Deny, Allow
+		if e.OP == operator.OR {
+			shortCircuit, skip = Allow, Deny
+		}
+
+		residuals := []ExprCell{}
+		for _, c := range e.Content {
+			decision, residual := c.PartialEval(data)
+			switch decision {
+			case shortCircuit:
+				return shortCircuit, ExprCell{}
+			case skip:
+				continue
+			default:
+				residuals = append(residuals, residual)
+			}
+		}
+
+		switch len(residuals) {
+		case 0:
+			// the empty AND is true, the empty OR is false, same as Eval
+			return skip, ExprCell{}
+		case 1:
+			return Undecided, residuals[0]
+		default:
+			return Undecided, ExprCell{OP: e.OP, Content: residuals}
+		}
+	case operator.Any:
+		return Allow, ExprCell{}
+	case "":
+		// no policy, same as Eval
+		return Deny, ExprCell{}
+	default:
+		if !hasAttribute(data, e.Field) {
+			return Undecided, *e
+		}
+		if evalBinaryOperator(e.OP, e.Field, e.Value, data) {
+			return Allow, ExprCell{}
+		}
+		return Deny, ExprCell{}
+	}
+}
+
+// hasAttribute will check if the object of ObjectSet has the attribute, the key is `type.attributeName`
+func hasAttribute(data ObjectSetInterface, key string) bool {
+	dotIdx := strings.IndexByte(key, '.')
+	if dotIdx == -1 {
+		return false
+	}
+
+	obj, exists := data.Get(key[:dotIdx])
+	if !exists {
+		return false
+	}
+
+	_, ok := obj[key[dotIdx+1:]]
+	return ok
+}
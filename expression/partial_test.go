@@ -0,0 +1,215 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("PartialEval", func() {
+	var o expression.ObjectSetInterface
+
+	BeforeEach(func() {
+		o = expression.NewObjectSet()
+		o.Set("host", map[string]interface{}{"id": "1", "owner": nil})
+	})
+
+	idEq := func(id string) expression.ExprCell {
+		return expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: id}
+	}
+	bizEq := func(id string) expression.ExprCell {
+		return expression.ExprCell{OP: operator.Eq, Field: "biz.id", Value: id}
+	}
+	levelGt := func(level int) expression.ExprCell {
+		return expression.ExprCell{OP: operator.Gt, Field: "host.level", Value: level}
+	}
+
+	It("leaf", func() {
+		e := idEq("1")
+		decision, residual := e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Allow, decision)
+		assert.Equal(GinkgoT(), expression.ExprCell{}, residual)
+
+		e = idEq("2")
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Deny, decision)
+
+		// the attribute with nil value is known
+		e = expression.ExprCell{OP: operator.Eq, Field: "host.owner", Value: "admin"}
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Deny, decision)
+
+		// the attribute not exists
+		e = levelGt(1)
+		decision, residual = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), levelGt(1), residual)
+
+		// the object not exists
+		e = bizEq("1")
+		decision, residual = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), bizEq("1"), residual)
+
+		e = expression.ExprCell{OP: operator.Any, Field: "biz.id"}
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Allow, decision)
+	})
+
+	It("no policy", func() {
+		e := expression.ExprCell{}
+		decision, residual := e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Deny, decision)
+		assert.Equal(GinkgoT(), expression.ExprCell{}, residual)
+		assert.False(GinkgoT(), e.Eval(o))
+
+		// nested in OR
+		e = expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{{}, bizEq("1")}}
+		decision, residual = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), bizEq("1"), residual)
+	})
+
+	It("AND", func() {
+		e := expression.ExprCell{OP: operator.AND, Content: []expression.ExprCell{idEq("1"), levelGt(1), bizEq("1")}}
+		decision, residual := e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.AND, Content: []expression.ExprCell{levelGt(1), bizEq("1")}}, residual)
+
+		e = expression.ExprCell{OP: operator.AND, Content: []expression.ExprCell{idEq("1"), levelGt(1)}}
+		decision, residual = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), levelGt(1), residual)
+
+		e = expression.ExprCell{OP: operator.AND, Content: []expression.ExprCell{levelGt(1), idEq("2")}}
+		decision, residual = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Deny, decision)
+		assert.Equal(GinkgoT(), expression.ExprCell{}, residual)
+
+		e = expression.ExprCell{OP: operator.AND}
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Allow, decision)
+	})
+
+	It("OR", func() {
+		e := expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{idEq("2"), levelGt(1), bizEq("1")}}
+		decision, residual := e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{levelGt(1), bizEq("1")}}, residual)
+
+		e = expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{levelGt(1), idEq("1")}}
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Allow, decision)
+
+		e = expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{idEq("2"), idEq("3")}}
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Deny, decision)
+
+		e = expression.ExprCell{OP: operator.OR}
+		decision, _ = e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Deny, decision)
+	})
+
+	It("nested", func() {
+		e := expression.ExprCell{
+			OP: operator.OR,
+			Content: []expression.ExprCell{
+				{OP: operator.AND, Content: []expression.ExprCell{idEq("1"), bizEq("1")}},
+				{OP: operator.AND, Content: []expression.ExprCell{idEq("2"), levelGt(1)}},
+				{OP: operator.AND, Content: []expression.ExprCell{levelGt(2), bizEq("2")}},
+			},
+		}
+
+		decision, residual := e.PartialEval(o)
+		assert.Equal(GinkgoT(), expression.Undecided, decision)
+		assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{
+			bizEq("1"),
+			{OP: operator.AND, Content: []expression.ExprCell{levelGt(2), bizEq("2")}},
+		}}, residual)
+		assert.Equal(GinkgoT(), "((biz.id eq 1) OR ((host.level gt 2) AND (biz.id eq 2)))", residual.String())
+	})
+
+	It("same as Eval", func() {
+		r := rand.New(rand.NewSource(1))
+		fields := []string{"host.id", "host.level", "biz.id", "biz.level"}
+		values := []interface{}{"1", "2", 1, 2}
+
+		var randExpr func(depth int) expression.ExprCell
+		randExpr = func(depth int) expression.ExprCell {
+			if depth > 0 && r.Intn(3) > 0 {
+				op := operator.AND
+				if r.Intn(2) == 0 {
+					op = operator.OR
+				}
+				content := []expression.ExprCell{}
+				for n := r.Intn(4); n > 0; n-- {
+					content = append(content, randExpr(depth-1))
+				}
+				return expression.ExprCell{OP: op, Content: content}
+			}
+
+			ops := []operator.OP{operator.Eq, operator.NotEq, operator.Gt, operator.Lte, operator.Any}
+			return expression.ExprCell{
+				OP:    ops[r.Intn(len(ops))],
+				Field: fields[r.Intn(len(fields))],
+				Value: values[r.Intn(len(values))],
+			}
+		}
+
+		for n := 0; n < 500; n++ {
+			e := randExpr(3)
+
+			// the full object set and the partial one with some attributes removed
+			full := expression.NewObjectSet()
+			partial := expression.NewObjectSet()
+			for _, t := range []string{"host", "biz"} {
+				fullAttrs := map[string]interface{}{}
+				partialAttrs := map[string]interface{}{}
+				for _, attr := range []string{"id", "level"} {
+					value := values[r.Intn(len(values))]
+					fullAttrs[attr] = value
+					if r.Intn(2) == 0 {
+						partialAttrs[attr] = value
+					}
+				}
+				full.Set(t, fullAttrs)
+				partial.Set(t, partialAttrs)
+			}
+
+			expected := e.Eval(full)
+			decision, residual := e.PartialEval(partial)
+			switch decision {
+			case expression.Allow:
+				assert.True(GinkgoT(), expected, e.String())
+			case expression.Deny:
+				assert.False(GinkgoT(), expected, e.String())
+			default:
+				assert.Equal(GinkgoT(), expected, residual.Eval(full), e.String())
+				// the residual only references the unknown fields
+				d, _ := residual.PartialEval(partial)
+				assert.Equal(GinkgoT(), expression.Undecided, d, residual.String())
+			}
+		}
+	})
+
+	It("Decision String", func() {
+		assert.Equal(GinkgoT(), "allow", expression.Allow.String())
+		assert.Equal(GinkgoT(), "deny", expression.Deny.String())
+		assert.Equal(GinkgoT(), "undecided", expression.Undecided.String())
+	})
+})
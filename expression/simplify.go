@@ -0,0 +1,222 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression
+
+import (
+	"reflect"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// Simplify will return the simplified expression, which evals exactly the same as the original one:
+// - flatten the nested AND/OR, unwrap the AND/OR with only one expression
+// - remove the duplicate expressions
+// - fold the `any`: drop it from AND, the OR contains it is `any`
+// - fold the always false expressions (e.g. `in` with an empty array, `eq` with an array), same as Eval
+// - merge the `eq`/`in` of the same field under OR into one `in`
+// - detect the contradiction `eq v AND not_eq v` of the same field
+//
+// the always true expression is simplified to `any`, the always false one to an OR without content
+func Simplify(expr ExprCell) ExprCell {
+	switch expr.OP {
+	case operator.AND, operator.OR:
+		return simplifyLogic(expr.OP, expr.Content)
+	case operator.Any, "":
+		// keep the empty expression, it means no policy
+		return expr
+	default:
+		if isAlwaysFalse(expr) {
+			return falseExpr()
+		}
+		return expr
+	}
+}
+
+func trueExpr() ExprCell {
+	return ExprCell{OP: operator.Any}
+}
+
+func falseExpr() ExprCell {
+	return ExprCell{OP: operator.OR, Content: []ExprCell{}}
+}
+
+func isTrueExpr(e ExprCell) bool {
+	return e.OP == operator.Any
+}
+
+func isFalseExpr(e ExprCell) bool {
+	return e.OP == operator.OR && len(e.Content) == 0
+}
+
+func simplifyLogic(op operator.OP, content []ExprCell) ExprCell {
+	// AND: drop the true, short-circuit on the false; OR: drop the false, short-circuit on the true
+	isIdentity, isAbsorbing := isTrueExpr, isFalseExpr
+	if op == operator.OR {
+		isIdentity, isAbsorbing = isFalseExpr, isTrueExpr
+	}
+
+	children := make([]ExprCell, 0, len(content))
+	var absorbing ExprCell
+	var add func(c ExprCell) bool
+	add = func(c ExprCell) bool {
+		switch {
+		case isAbsorbing(c):
+			absorbing = c
+			return false
+		case isIdentity(c):
+			return true
+		case c.OP == op:
+			// flatten, the content is simplified already
+			for _, cc := range c.Content {
+				if !add(cc) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, exist := range children {
+			if reflect.DeepEqual(exist, c) {
+				return true
+			}
+		}
+		children = append(children, c)
+		return true
+	}
+
+	for _, c := range content {
+		if !add(Simplify(c)) {
+			return absorbing
+		}
+	}
+
+	if op == operator.OR {
+		children = mergeEqualsUnderOR(children)
+	} else if hasContradiction(children) {
+		return falseExpr()
+	}
+
+	switch len(children) {
+	case 0:
+		// the empty AND is true, the empty OR is false, same as Eval
+		if op == operator.AND {
+			return trueExpr()
+		}
+		return falseExpr()
+	case 1:
+		return children[0]
+	default:
+		return ExprCell{OP: op, Content: children}
+	}
+}
+
+// isAlwaysFalse will check if the binary expression is always false whatever the object is,
+// same as the value type checks in evalBinaryOperator
+func isAlwaysFalse(e ExprCell) bool {
+	switch e.OP {
+	case operator.Eq, operator.Lt, operator.Lte, operator.Gt, operator.Gte,
+		operator.NotEq, operator.NotStartsWith, operator.NotEndsWith,
+		operator.Contains, operator.NotContains:
+		return isValueTypeArray(e.Value)
+	case operator.StartsWith, operator.EndsWith, operator.StringContains:
+		// both the object value and the policy value should be string
+		_, ok := e.Value.(string)
+		return !ok
+	case operator.In:
+		return !isValueTypeArray(e.Value) || reflect.ValueOf(e.Value).Len() == 0
+	case operator.NotIn:
+		return !isValueTypeArray(e.Value)
+	default:
+		return true
+	}
+}
+
+// mergeEqualsUnderOR will merge the `eq` and `in` of the same field into one `in`, at the position of the first one;
+// only the `in` with []interface{} value is merged, the `in` with []string value is evaluated by string comparison,
+// while the `eq` and the `in` with []interface{} value are evaluated by reflect.DeepEqual
+func mergeEqualsUnderOR(children []ExprCell) []ExprCell {
+	values := map[string][]interface{}{}
+	counts := map[string]int{}
+	for _, c := range children {
+		if vs, ok := equalValues(c); ok {
+			counts[c.Field]++
+			for _, v := range vs {
+				if !containsValue(values[c.Field], v) {
+					values[c.Field] = append(values[c.Field], v)
+				}
+			}
+		}
+	}
+
+	merged := make([]ExprCell, 0, len(children))
+	for _, c := range children {
+		if _, ok := equalValues(c); !ok || counts[c.Field] < 2 {
+			merged = append(merged, c)
+			continue
+		}
+
+		vs, ok := values[c.Field]
+		if !ok {
+			// merged already
+			continue
+		}
+		delete(values, c.Field)
+
+		if len(vs) == 1 {
+			merged = append(merged, ExprCell{OP: operator.Eq, Field: c.Field, Value: vs[0]})
+		} else {
+			merged = append(merged, ExprCell{OP: operator.In, Field: c.Field, Value: vs})
+		}
+	}
+	return merged
+}
+
+// equalValues return the values of the mergeable `eq` or `in`
+func equalValues(e ExprCell) ([]interface{}, bool) {
+	switch e.OP {
+	case operator.Eq:
+		if isValueTypeArray(e.Value) {
+			return nil, false
+		}
+		return []interface{}{e.Value}, true
+	case operator.In:
+		vs, ok := e.Value.([]interface{})
+		return vs, ok
+	default:
+		return nil, false
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasContradiction will check if the AND contains `eq v` and `not_eq v` of the same field,
+// `eq v` is true if any value of the object equals to v, while `not_eq v` is true if all values not equal to v
+func hasContradiction(children []ExprCell) bool {
+	for _, c := range children {
+		if c.OP != operator.Eq {
+			continue
+		}
+		for _, other := range children {
+			if other.OP == operator.NotEq && other.Field == c.Field && reflect.DeepEqual(other.Value, c.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("Simplify", func() {
+	eq := func(field string, value interface{}) expression.ExprCell {
+		return expression.ExprCell{OP: operator.Eq, Field: field, Value: value}
+	}
+	in := func(field string, values ...interface{}) expression.ExprCell {
+		return expression.ExprCell{OP: operator.In, Field: field, Value: values}
+	}
+	and := func(content ...expression.ExprCell) expression.ExprCell {
+		return expression.ExprCell{OP: operator.AND, Content: content}
+	}
+	or := func(content ...expression.ExprCell) expression.ExprCell {
+		return expression.ExprCell{OP: operator.OR, Content: content}
+	}
+	any := expression.ExprCell{OP: operator.Any, Field: "host.id", Value: []interface{}{}}
+	alwaysFalse := expression.ExprCell{OP: operator.OR, Content: []expression.ExprCell{}}
+
+	It("flatten and unwrap", func() {
+		assert.Equal(GinkgoT(), eq("host.id", "1"), expression.Simplify(and(or(and(eq("host.id", "1"))))))
+		assert.Equal(GinkgoT(),
+			and(eq("host.id", "1"), eq("host.owner", "admin"), eq("biz.id", "2")),
+			expression.Simplify(and(eq("host.id", "1"), and(eq("host.owner", "admin"), and(eq("biz.id", "2"))))))
+	})
+
+	It("remove duplicates", func() {
+		assert.Equal(GinkgoT(),
+			and(eq("host.id", "1"), eq("host.owner", "admin")),
+			expression.Simplify(and(eq("host.id", "1"), eq("host.owner", "admin"), and(eq("host.id", "1")))))
+	})
+
+	It("fold any", func() {
+		assert.Equal(GinkgoT(), eq("host.id", "1"), expression.Simplify(and(any, eq("host.id", "1"))))
+		assert.Equal(GinkgoT(), any, expression.Simplify(or(eq("host.id", "1"), and(any, eq("biz.id", "1")), any)))
+		assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.Any}, expression.Simplify(or(eq("host.id", "1"), and(any, any))))
+		assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.Any}, expression.Simplify(and(any, any)))
+		assert.Equal(GinkgoT(), any, expression.Simplify(any))
+	})
+
+	It("fold always false", func() {
+		assert.Equal(GinkgoT(), alwaysFalse, expression.Simplify(in("host.id")))
+		assert.Equal(GinkgoT(), alwaysFalse, expression.Simplify(eq("host.id", []interface{}{"1"})))
+		assert.Equal(GinkgoT(), alwaysFalse, expression.Simplify(and(eq("host.id", "1"), in("host.owner"))))
+		assert.Equal(GinkgoT(), eq("host.id", "1"), expression.Simplify(or(eq("host.id", "1"), in("host.owner"))))
+		assert.Equal(GinkgoT(), alwaysFalse, expression.Simplify(
+			expression.ExprCell{OP: operator.StartsWith, Field: "host.name", Value: 1}))
+		assert.Equal(GinkgoT(), alwaysFalse, expression.Simplify(or()))
+		assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.Any}, expression.Simplify(and()))
+
+		// the empty expression is kept
+		assert.Equal(GinkgoT(), expression.ExprCell{}, expression.Simplify(expression.ExprCell{}))
+	})
+
+	It("merge eq and in under OR", func() {
+		assert.Equal(GinkgoT(),
+			or(in("host.id", "1", "2", "3"), eq("host.owner", "admin")),
+			expression.Simplify(or(eq("host.id", "1"), eq("host.owner", "admin"), in("host.id", "2", "1"), eq("host.id", "3"))))
+		assert.Equal(GinkgoT(), eq("host.id", "1"), expression.Simplify(or(eq("host.id", "1"), in("host.id", "1"))))
+
+		// the []string value is not merged
+		stringsIn := expression.ExprCell{OP: operator.In, Field: "host.id", Value: []string{"2"}}
+		assert.Equal(GinkgoT(), or(eq("host.id", "1"), stringsIn), expression.Simplify(or(eq("host.id", "1"), stringsIn)))
+
+		// not merged under AND
+		assert.Equal(GinkgoT(),
+			and(eq("host.id", "1"), in("host.id", "2")),
+			expression.Simplify(and(eq("host.id", "1"), in("host.id", "2"))))
+	})
+
+	It("contradiction", func() {
+		notEq := expression.ExprCell{OP: operator.NotEq, Field: "host.id", Value: "1"}
+		assert.Equal(GinkgoT(), alwaysFalse, expression.Simplify(and(eq("host.id", "1"), eq("biz.id", "2"), notEq)))
+		assert.Equal(GinkgoT(), eq("biz.id", "2"), expression.Simplify(or(and(eq("host.id", "1"), notEq), eq("biz.id", "2"))))
+	})
+
+	It("same as Eval", func() {
+		r := rand.New(rand.NewSource(1))
+		fields := []string{"host.id", "host.tags", "biz.id"}
+		values := []interface{}{"1", "2", 1, nil}
+		ops := []operator.OP{
+			operator.Eq, operator.Eq, operator.NotEq, operator.In, operator.In, operator.NotIn,
+			operator.Contains, operator.StartsWith, operator.Gt, operator.Any,
+		}
+
+		randValue := func() interface{} {
+			switch r.Intn(6) {
+			case 0:
+				return []interface{}{}
+			case 1:
+				return []interface{}{values[r.Intn(len(values))], values[r.Intn(len(values))]}
+			case 2:
+				return []string{"1"}
+			default:
+				return values[r.Intn(len(values))]
+			}
+		}
+
+		var randExpr func(depth int) expression.ExprCell
+		randExpr = func(depth int) expression.ExprCell {
+			if depth > 0 && r.Intn(3) > 0 {
+				content := []expression.ExprCell{}
+				for n := r.Intn(5); n > 0; n-- {
+					content = append(content, randExpr(depth-1))
+				}
+				// duplicates
+				if len(content) > 0 && r.Intn(3) == 0 {
+					content = append(content, content[0])
+				}
+				if r.Intn(2) == 0 {
+					return and(content...)
+				}
+				return or(content...)
+			}
+
+			return expression.ExprCell{
+				OP:    ops[r.Intn(len(ops))],
+				Field: fields[r.Intn(len(fields))],
+				Value: randValue(),
+			}
+		}
+
+		for n := 0; n < 2000; n++ {
+			e := randExpr(4)
+			simplified := expression.Simplify(e)
+
+			for m := 0; m < 5; m++ {
+				o := expression.NewObjectSet()
+				o.Set("host", map[string]interface{}{"id": randValue(), "tags": randValue()})
+				if r.Intn(4) > 0 {
+					o.Set("biz", map[string]interface{}{"id": randValue()})
+				}
+
+				assert.Equal(GinkgoT(), e.Eval(o), simplified.Eval(o), "%s => %s", e.String(), simplified.String())
+			}
+		}
+	})
+})
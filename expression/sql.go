@@ -0,0 +1,280 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+const (
+	sqlTrue  = "1 = 1"
+	sqlFalse = "1 = 0"
+
+	// the escape char of LIKE patterns, same for all the dialects
+	sqlLikeEscape = '!'
+)
+
+var sqlCompareOperators = map[operator.OP]string{
+	operator.Eq:    "=",
+	operator.NotEq: "<>",
+	operator.Lt:    "<",
+	operator.Lte:   "<=",
+	operator.Gt:    ">",
+	operator.Gte:   ">=",
+}
+
+// ErrUnmappableField is returned by ToSQL/ToESQuery if the field of the expression is not in the mapping
+var ErrUnmappableField = errors.New("unmappable field")
+
+// SQLDialect is the hooks of the sql dialect
+type SQLDialect interface {
+	// QuoteIdentifier quote the column name, `table.column` should be quoted as two identifiers
+	QuoteIdentifier(name string) string
+	// Placeholder return the placeholder of the n-th(start from 1) arg
+	Placeholder(n int) string
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+func (mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+func (sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+var (
+	// MySQL quote with `, placeholder ?
+	MySQL SQLDialect = mysqlDialect{}
+	// PostgreSQL quote with ", placeholder $1, $2...
+	PostgreSQL SQLDialect = postgresDialect{}
+	// SQLite quote with ", placeholder ?
+	SQLite SQLDialect = sqliteDialect{}
+)
+
+func quoteIdentifier(name, quote string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// SQLOption is the option of ToSQL
+type SQLOption func(b *sqlBuilder)
+
+// WithSQLDialect will set the dialect of ToSQL, default is MySQL
+func WithSQLDialect(dialect SQLDialect) SQLOption {
+	return func(b *sqlBuilder) {
+		b.dialect = dialect
+	}
+}
+
+type sqlBuilder struct {
+	dialect SQLDialect
+	mapping map[string]string
+	args    []interface{}
+}
+
+// ToSQL will translate the expression into a parameterized sql WHERE fragment and the args,
+// the mapping is from the field(`type.attr`, e.g. `host.id`, `host._bk_iam_path_`) to the column(`column` or `table.column`)
+//
+// the operators follow Eval for an object with single value attributes, so:
+// - contains/not_contains (the attribute should be an array) is always false
+// - not_eq/not_in is true for NULL column, while starts_with/ends_with/string_contains and the negations are false
+// - eq nil is translated to IS NULL, not_eq nil to IS NOT NULL
+//
+// NOTE: the comparisons are done by the database, which may differ from Eval:
+// - MySQL: the default `_ci` collations ignore the case in =/IN/LIKE (use a `_bin` collation column for exact match)
+// - MySQL: the PAD SPACE collations ignore the trailing spaces in =/</>
+// - MySQL: the string and the number are converted to compare, e.g. `'1a' = 1` is true
+// - PostgreSQL: =/IN/LIKE are case-sensitive, the arg is converted to the type of the column
+// - SQLite: =/IN are case-sensitive, LIKE ignores the case of ASCII chars unless `PRAGMA case_sensitive_like`
+// - SQLite: the arg is converted by the affinity of the column
+//
+// while Eval is case-sensitive and never treats the values of different types as equal,
+// so keep the values of the expression in the same type as the columns
+func ToSQL(expr ExprCell, mapping map[string]string, opts ...SQLOption) (where string, args []interface{}, err error) {
+	b := &sqlBuilder{
+		dialect: MySQL,
+		mapping: mapping,
+		args:    []interface{}{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	where, err = b.build(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, b.args, nil
+}
+
+func (b *sqlBuilder) build(e ExprCell) (string, error) {
+	switch e.OP {
+	case operator.AND, operator.OR:
+		if len(e.Content) == 0 {
+			if e.OP == operator.AND {
+				return sqlTrue, nil
+			}
+			return sqlFalse, nil
+		}
+
+		subs := make([]string, 0, len(e.Content))
+		for _, c := range e.Content {
+			sub, err := b.build(c)
+			if err != nil {
+				return "", err
+			}
+			subs = append(subs, sub)
+		}
+		return "(" + strings.Join(subs, fmt.Sprintf(" %s ", e.OP)) + ")", nil
+	case operator.Any:
+		return sqlTrue, nil
+	case "":
+		// no policy, same as Eval
+		return sqlFalse, nil
+	default:
+		return b.buildBinary(e.OP, e.Field, e.Value)
+	}
+}
+
+func (b *sqlBuilder) buildBinary(op operator.OP, field string, value interface{}) (string, error) {
+	column, ok := b.mapping[field]
+	if !ok || column == "" {
+		return "", fmt.Errorf("the field `%s` has no column in the mapping: %w", field, ErrUnmappableField)
+	}
+	column = b.dialect.QuoteIdentifier(column)
+
+	// support _bk_iam_path_, starts with from `/a,1/b,*/` to `/a,1/b,`
+	if op == operator.StartsWith && strings.HasSuffix(field, KeywordBKIAMPathFieldSuffix) {
+		if v, ok := value.(string); ok && strings.HasSuffix(v, ",*/") {
+			value = strings.TrimSuffix(v, "*/")
+		}
+	}
+
+	switch op {
+	case operator.Eq, operator.NotEq, operator.Lt, operator.Lte, operator.Gt, operator.Gte:
+		if isValueTypeArray(value) {
+			return sqlFalse, nil
+		}
+
+		// `= NULL` is never true, the NULL column equals to nil
+		if value == nil && op == operator.Eq {
+			return fmt.Sprintf("%s IS NULL", column), nil
+		}
+		if value == nil && op == operator.NotEq {
+			return fmt.Sprintf("%s IS NOT NULL", column), nil
+		}
+
+		where := fmt.Sprintf("%s %s %s", column, sqlCompareOperators[op], b.bind(value))
+		if op == operator.NotEq {
+			where = fmt.Sprintf("(%s OR %s IS NULL)", where, column)
+		}
+		return where, nil
+	case operator.In, operator.NotIn:
+		if !isValueTypeArray(value) {
+			return sqlFalse, nil
+		}
+
+		listValue := reflect.ValueOf(value)
+		if listValue.Len() == 0 {
+			if op == operator.In {
+				return sqlFalse, nil
+			}
+			return sqlTrue, nil
+		}
+
+		placeholders := make([]string, 0, listValue.Len())
+		for i := 0; i < listValue.Len(); i++ {
+			placeholders = append(placeholders, b.bind(listValue.Index(i).Interface()))
+		}
+		if op == operator.In {
+			return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")), nil
+		}
+		return fmt.Sprintf("(%s NOT IN (%s) OR %s IS NULL)", column, strings.Join(placeholders, ", "), column), nil
+	case operator.StartsWith, operator.NotStartsWith,
+		operator.EndsWith, operator.NotEndsWith,
+		operator.StringContains:
+		v, ok := value.(string)
+		if !ok {
+			return sqlFalse, nil
+		}
+
+		pattern := escapeLike(v)
+		switch op {
+		case operator.StartsWith, operator.NotStartsWith:
+			pattern += "%"
+		case operator.EndsWith, operator.NotEndsWith:
+			pattern = "%" + pattern
+		default:
+			pattern = "%" + pattern + "%"
+		}
+
+		like := "LIKE"
+		if op == operator.NotStartsWith || op == operator.NotEndsWith {
+			like = "NOT LIKE"
+		}
+		return fmt.Sprintf("%s %s %s ESCAPE '%c'", column, like, b.bind(pattern), sqlLikeEscape), nil
+	case operator.Contains, operator.NotContains:
+		// the column is a single value, never be an array
+		return sqlFalse, nil
+	default:
+		return "", fmt.Errorf("the operator `%s` of field `%s` is not supported", op, field)
+	}
+}
+
+// bind will add the arg and return the placeholder of it
+func (b *sqlBuilder) bind(value interface{}) string {
+	b.args = append(b.args, value)
+	return b.dialect.Placeholder(len(b.args))
+}
+
+// escapeLike will escape the wildcards of LIKE pattern with sqlLikeEscape
+func escapeLike(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '%' || r == '_' || r == sqlLikeEscape {
+			sb.WriteRune(sqlLikeEscape)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
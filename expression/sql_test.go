@@ -0,0 +1,212 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("SQL", func() {
+	mapping := map[string]string{
+		"host.id":            "id",
+		"host.name":          "host.name",
+		"host.level":         "level",
+		"host._bk_iam_path_": "path",
+	}
+
+	type sqlCase struct {
+		expr  expression.ExprCell
+		where string
+		args  []interface{}
+	}
+
+	assertCases := func(cases []sqlCase, opts ...expression.SQLOption) {
+		for _, c := range cases {
+			where, args, err := expression.ToSQL(c.expr, mapping, opts...)
+
+			assert.NoError(GinkgoT(), err, c.expr.String())
+			assert.Equal(GinkgoT(), c.where, where, c.expr.String())
+			assert.Equal(GinkgoT(), c.args, args, c.expr.String())
+		}
+	}
+
+	It("binary operators", func() {
+		assertCases([]sqlCase{
+			{
+				expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: "1"},
+				"`id` = ?", []interface{}{"1"},
+			},
+			{
+				expression.ExprCell{OP: operator.NotEq, Field: "host.id", Value: "1"},
+				"(`id` <> ? OR `id` IS NULL)", []interface{}{"1"},
+			},
+			{
+				expression.ExprCell{OP: operator.In, Field: "host.id", Value: []interface{}{"1", "2"}},
+				"`id` IN (?, ?)", []interface{}{"1", "2"},
+			},
+			{
+				expression.ExprCell{OP: operator.NotIn, Field: "host.id", Value: []string{"1", "2"}},
+				"(`id` NOT IN (?, ?) OR `id` IS NULL)", []interface{}{"1", "2"},
+			},
+			{
+				expression.ExprCell{OP: operator.Lt, Field: "host.level", Value: 1},
+				"`level` < ?", []interface{}{1},
+			},
+			{
+				expression.ExprCell{OP: operator.Lte, Field: "host.level", Value: 1},
+				"`level` <= ?", []interface{}{1},
+			},
+			{
+				expression.ExprCell{OP: operator.Gt, Field: "host.level", Value: 1},
+				"`level` > ?", []interface{}{1},
+			},
+			{
+				expression.ExprCell{OP: operator.Gte, Field: "host.level", Value: 1},
+				"`level` >= ?", []interface{}{1},
+			},
+			{
+				expression.ExprCell{OP: operator.StartsWith, Field: "host.name", Value: "a_b"},
+				"`host`.`name` LIKE ? ESCAPE '!'", []interface{}{"a!_b%"},
+			},
+			{
+				expression.ExprCell{OP: operator.NotStartsWith, Field: "host.name", Value: "a"},
+				"`host`.`name` NOT LIKE ? ESCAPE '!'", []interface{}{"a%"},
+			},
+			{
+				expression.ExprCell{OP: operator.EndsWith, Field: "host.name", Value: "50%"},
+				"`host`.`name` LIKE ? ESCAPE '!'", []interface{}{"%50!%"},
+			},
+			{
+				expression.ExprCell{OP: operator.NotEndsWith, Field: "host.name", Value: "a!"},
+				"`host`.`name` NOT LIKE ? ESCAPE '!'", []interface{}{"%a!!"},
+			},
+			{
+				expression.ExprCell{OP: operator.StringContains, Field: "host.name", Value: "a"},
+				"`host`.`name` LIKE ? ESCAPE '!'", []interface{}{"%a%"},
+			},
+			{
+				expression.ExprCell{OP: operator.Any, Field: "host.id", Value: []interface{}{}},
+				"1 = 1", []interface{}{},
+			},
+		})
+	})
+
+	It("always false or true", func() {
+		assertCases([]sqlCase{
+			// the value type mismatch the operator
+			{expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: []interface{}{"1"}}, "1 = 0", []interface{}{}},
+			{expression.ExprCell{OP: operator.NotEq, Field: "host.id", Value: []interface{}{"1"}}, "1 = 0", []interface{}{}},
+			{expression.ExprCell{OP: operator.In, Field: "host.id", Value: "1"}, "1 = 0", []interface{}{}},
+			{expression.ExprCell{OP: operator.NotIn, Field: "host.id", Value: "1"}, "1 = 0", []interface{}{}},
+			{expression.ExprCell{OP: operator.StartsWith, Field: "host.name", Value: 1}, "1 = 0", []interface{}{}},
+			// empty in and not_in
+			{expression.ExprCell{OP: operator.In, Field: "host.id", Value: []interface{}{}}, "1 = 0", []interface{}{}},
+			{expression.ExprCell{OP: operator.NotIn, Field: "host.id", Value: []interface{}{}}, "1 = 1", []interface{}{}},
+			// the column is not an array
+			{expression.ExprCell{OP: operator.Contains, Field: "host.id", Value: "1"}, "1 = 0", []interface{}{}},
+			{expression.ExprCell{OP: operator.NotContains, Field: "host.id", Value: "1"}, "1 = 0", []interface{}{}},
+			// empty AND and OR
+			{expression.ExprCell{OP: operator.AND}, "1 = 1", []interface{}{}},
+			{expression.ExprCell{OP: operator.OR}, "1 = 0", []interface{}{}},
+			// no policy
+			{expression.ExprCell{}, "1 = 0", []interface{}{}},
+		})
+	})
+
+	It("nil value", func() {
+		assertCases([]sqlCase{
+			{expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: nil}, "`id` IS NULL", []interface{}{}},
+			{expression.ExprCell{OP: operator.NotEq, Field: "host.id", Value: nil}, "`id` IS NOT NULL", []interface{}{}},
+		})
+	})
+
+	It("_bk_iam_path_", func() {
+		assertCases([]sqlCase{
+			{
+				expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"},
+				"`path` LIKE ? ESCAPE '!'", []interface{}{"/biz,1/set,%"},
+			},
+			{
+				expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/"},
+				"`path` LIKE ? ESCAPE '!'", []interface{}{"/biz,1/%"},
+			},
+		})
+	})
+
+	It("AND and OR", func() {
+		assertCases([]sqlCase{
+			{
+				expression.ExprCell{
+					OP: operator.OR,
+					Content: []expression.ExprCell{
+						{OP: operator.Eq, Field: "host.id", Value: "1"},
+						{
+							OP: operator.AND,
+							Content: []expression.ExprCell{
+								{OP: operator.Gt, Field: "host.level", Value: 2},
+								{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/"},
+							},
+						},
+					},
+				},
+				"(`id` = ? OR (`level` > ? AND `path` LIKE ? ESCAPE '!'))", []interface{}{"1", 2, "/biz,1/%"},
+			},
+		})
+	})
+
+	It("dialects", func() {
+		expr := expression.ExprCell{
+			OP: operator.AND,
+			Content: []expression.ExprCell{
+				{OP: operator.In, Field: "host.id", Value: []interface{}{"1", "2"}},
+				{OP: operator.Eq, Field: "host.name", Value: "a"},
+			},
+		}
+
+		assertCases([]sqlCase{
+			{expr, `("id" IN ($1, $2) AND "host"."name" = $3)`, []interface{}{"1", "2", "a"}},
+		}, expression.WithSQLDialect(expression.PostgreSQL))
+		assertCases([]sqlCase{
+			{expr, `("id" IN (?, ?) AND "host"."name" = ?)`, []interface{}{"1", "2", "a"}},
+		}, expression.WithSQLDialect(expression.SQLite))
+	})
+
+	It("quote identifier", func() {
+		assert.Equal(GinkgoT(), "`a``b`", expression.MySQL.QuoteIdentifier("a`b"))
+		assert.Equal(GinkgoT(), `"a""b"`, expression.PostgreSQL.QuoteIdentifier(`a"b`))
+	})
+
+	It("unmappable field", func() {
+		_, _, err := expression.ToSQL(expression.ExprCell{
+			OP: operator.AND,
+			Content: []expression.ExprCell{
+				{OP: operator.Eq, Field: "host.id", Value: "1"},
+				{OP: operator.Eq, Field: "host.owner", Value: "admin"},
+			},
+		}, mapping)
+
+		assert.True(GinkgoT(), errors.Is(err, expression.ErrUnmappableField))
+		assert.Contains(GinkgoT(), err.Error(), "host.owner")
+	})
+
+	It("unsupported operator", func() {
+		_, _, err := expression.ToSQL(expression.ExprCell{OP: "regex", Field: "host.id", Value: "1"}, mapping)
+
+		assert.ErrorContains(GinkgoT(), err, "regex")
+	})
+})
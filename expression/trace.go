@@ -0,0 +1,296 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// TraceReason is the reason code of the result of a node in the Trace
+type TraceReason string
+
+const (
+	// ReasonMatched the node is true
+	ReasonMatched TraceReason = "matched"
+	// ReasonNotMatched the node is false, the values are comparable but not matched
+	ReasonNotMatched TraceReason = "not_matched"
+	// ReasonMissingAttribute the object or the attribute of the field not exists in the ObjectSet
+	ReasonMissingAttribute TraceReason = "missing_attribute"
+	// ReasonShapeMismatch the array/scalar shape of the values mismatch the operator,
+	// e.g. `eq` with an array policy value, `in` with a scalar policy value, `contains` with a scalar object value
+	ReasonShapeMismatch TraceReason = "shape_mismatch"
+	// ReasonTypeIncomparable the types of the object value and the policy value are incomparable,
+	// e.g. `gt` between a string and a number, `starts_with` with a number
+	ReasonTypeIncomparable TraceReason = "type_incomparable"
+	// ReasonSkipped the node is not evaluated, short-circuited by AND/OR
+	ReasonSkipped TraceReason = "skipped"
+	// ReasonUnsupportedOperator the operator is not supported, evaluated as false
+	ReasonUnsupportedOperator TraceReason = "unsupported_operator"
+	// ReasonNoPolicy the expression is empty, there is no policy
+	ReasonNoPolicy TraceReason = "no_policy"
+)
+
+// Trace is the evaluation trace of an expression cell, Children is the traces of the content of AND/OR
+type Trace struct {
+	OP          operator.OP `json:"op"`
+	Field       string      `json:"field,omitempty"`
+	ObjectValue interface{} `json:"object_value,omitempty"`
+	PolicyValue interface{} `json:"policy_value,omitempty"`
+	Result      bool        `json:"result"`
+	Reason      TraceReason `json:"reason"`
+	Children    []*Trace    `json:"children,omitempty"`
+}
+
+// EvalWithTrace will evaluate the expression with ObjectSet same as Eval, and return the trace of each node
+func (e *ExprCell) EvalWithTrace(data ObjectSetInterface) (bool, *Trace) {
+	t := e.trace(data)
+	return t.Result, t
+}
+
+// String return the text of the trace, one node per line, indented by the depth
+func (t *Trace) String() string {
+	var b strings.Builder
+	t.render(&b, 0)
+	return b.String()
+}
+
+// JSON return the indented json of the trace
+func (t *Trace) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+func (t *Trace) render(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+
+	switch t.OP {
+	case operator.AND, operator.OR:
+		b.WriteString(string(t.OP))
+	default:
+		fmt.Fprintf(b, "(%v %s %v)", t.Field, t.OP, t.PolicyValue)
+	}
+
+	if t.Reason == ReasonSkipped {
+		b.WriteString(" => skipped\n")
+	} else {
+		if t.OP != operator.AND && t.OP != operator.OR {
+			fmt.Fprintf(b, " object value: %v", t.ObjectValue)
+		}
+		fmt.Fprintf(b, " => %t (%s)\n", t.Result, t.Reason)
+	}
+
+	for _, c := range t.Children {
+		c.render(b, depth+1)
+	}
+}
+
+func (e *ExprCell) trace(data ObjectSetInterface) *Trace {
+	switch e.OP {
+	case operator.AND, operator.OR:
+		// the empty AND is true, the empty OR is false; a false child decides the AND, a true child decides the OR
+		t := &Trace{OP: e.OP, Result: e.OP == operator.AND, Children: make([]*Trace, 0, len(e.Content))}
+		decided := false
+		for i := range e.Content {
+			if decided {
+				t.Children = append(t.Children, e.Content[i].skippedTrace())
+				continue
+			}
+
+			c := e.Content[i].trace(data)
+			t.Children = append(t.Children, c)
+			if c.Result != t.Result {
+				t.Result = c.Result
+				decided = true
+			}
+		}
+
+		t.Reason = ReasonNotMatched
+		if t.Result {
+			t.Reason = ReasonMatched
+		}
+		return t
+	default:
+		objectValue := data.GetAttribute(e.Field)
+		result := evalBinaryOperator(e.OP, e.Field, e.Value, data)
+		return &Trace{
+			OP:          e.OP,
+			Field:       e.Field,
+			ObjectValue: objectValue,
+			PolicyValue: e.Value,
+			Result:      result,
+			Reason:      binaryOperatorReason(e.OP, e.Field, e.Value, objectValue, result, data),
+		}
+	}
+}
+
+func (e *ExprCell) skippedTrace() *Trace {
+	t := &Trace{OP: e.OP, Reason: ReasonSkipped}
+	switch e.OP {
+	case operator.AND, operator.OR:
+		t.Children = make([]*Trace, 0, len(e.Content))
+		for i := range e.Content {
+			t.Children = append(t.Children, e.Content[i].skippedTrace())
+		}
+	default:
+		t.Field = e.Field
+		t.PolicyValue = e.Value
+	}
+	return t
+}
+
+// binaryOperatorReason will explain the result of evalBinaryOperator
+func binaryOperatorReason(
+	op operator.OP,
+	field string,
+	policyValue, objectValue interface{},
+	result bool,
+	data ObjectSetInterface,
+) TraceReason {
+	switch op {
+	case "":
+		return ReasonNoPolicy
+	case operator.Any,
+		operator.Eq, operator.NotEq, operator.In, operator.NotIn, operator.Contains, operator.NotContains,
+		operator.StartsWith, operator.NotStartsWith, operator.EndsWith, operator.NotEndsWith, operator.StringContains,
+		operator.Lt, operator.Lte, operator.Gt, operator.Gte:
+	default:
+		return ReasonUnsupportedOperator
+	}
+
+	switch {
+	case result:
+		return ReasonMatched
+	case isPolicyValueShapeMismatch(op, policyValue):
+		return ReasonShapeMismatch
+	case !hasAttribute(data, field):
+		return ReasonMissingAttribute
+	case (op == operator.Contains || op == operator.NotContains) && !isValueTypeArray(objectValue):
+		return ReasonShapeMismatch
+	case isTypeIncomparable(op, objectValue, policyValue):
+		return ReasonTypeIncomparable
+	default:
+		return ReasonNotMatched
+	}
+}
+
+// isPolicyValueShapeMismatch same as the policy value checks in evalBinaryOperator
+func isPolicyValueShapeMismatch(op operator.OP, policyValue interface{}) bool {
+	switch op {
+	case operator.In, operator.NotIn:
+		return !isValueTypeArray(policyValue)
+	case operator.Any:
+		return false
+	default:
+		return isValueTypeArray(policyValue)
+	}
+}
+
+// isTypeIncomparable will check if the false result is caused by the incomparable types:
+// for the positive operators, none of the object values is comparable with the policy value;
+// for the negative operators, the first object value makes it false is incomparable with the policy value
+func isTypeIncomparable(op operator.OP, objectValue, policyValue interface{}) bool {
+	var values []interface{}
+	if isValueTypeArray(objectValue) {
+		listValue := reflect.ValueOf(objectValue)
+		values = make([]interface{}, 0, listValue.Len())
+		for i := 0; i < listValue.Len(); i++ {
+			values = append(values, listValue.Index(i).Interface())
+		}
+	} else {
+		values = []interface{}{objectValue}
+	}
+
+	switch op {
+	case operator.NotEq, operator.NotStartsWith, operator.NotEndsWith, operator.NotIn:
+		for _, v := range values {
+			if !evalNegative(op, v, policyValue) {
+				return !isTypeComparable(op, v, policyValue)
+			}
+		}
+		return false
+	case operator.NotContains:
+		// false only if one of the object values equals to the policy value
+		return false
+	default:
+		if len(values) == 0 {
+			return false
+		}
+		for _, v := range values {
+			if isTypeComparable(op, v, policyValue) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func isTypeComparable(op operator.OP, objectValue, policyValue interface{}) bool {
+	switch op {
+	case operator.Lt, operator.Lte, operator.Gt, operator.Gte:
+		return (isNumberValue(objectValue) && isNumberValue(policyValue)) ||
+			(isStringValue(objectValue) && isStringValue(policyValue))
+	case operator.StartsWith, operator.NotStartsWith, operator.EndsWith, operator.NotEndsWith,
+		operator.StringContains:
+		_, ok1 := objectValue.(string)
+		_, ok2 := policyValue.(string)
+		return ok1 && ok2
+	case operator.In, operator.NotIn:
+		listValue := reflect.ValueOf(policyValue)
+		if listValue.Len() == 0 {
+			return true
+		}
+		// the string kinds are compared by the string value, see eval.In
+		if objectValue != nil && reflect.TypeOf(objectValue).Kind() == reflect.String &&
+			listValue.Type().Elem().Kind() == reflect.String {
+			return true
+		}
+		for i := 0; i < listValue.Len(); i++ {
+			if isSameType(objectValue, listValue.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	default:
+		// eq, not_eq, contains
+		return isSameType(objectValue, policyValue)
+	}
+}
+
+func isSameType(v1, v2 interface{}) bool {
+	return v1 == nil || v2 == nil || reflect.TypeOf(v1) == reflect.TypeOf(v2)
+}
+
+func isNumberValue(v interface{}) bool {
+	if _, ok := v.(json.Number); ok {
+		return true
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isStringValue(v interface{}) bool {
+	if _, ok := v.(json.Number); ok {
+		return false
+	}
+	return reflect.ValueOf(v).Kind() == reflect.String
+}
@@ -0,0 +1,209 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云-权限中心Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("EvalWithTrace", func() {
+	var o expression.ObjectSetInterface
+
+	BeforeEach(func() {
+		o = expression.NewObjectSet()
+		o.Set("host", map[string]interface{}{
+			"id":    "1",
+			"tags":  []interface{}{"a", "b"},
+			"level": 3,
+			"name":  "web-1",
+		})
+	})
+
+	leafReason := func(e expression.ExprCell) expression.TraceReason {
+		allowed, trace := e.EvalWithTrace(o)
+		assert.Equal(GinkgoT(), e.Eval(o), allowed, e.String())
+		assert.Equal(GinkgoT(), allowed, trace.Result)
+		return trace.Reason
+	}
+
+	It("reason", func() {
+		assert.Equal(GinkgoT(), expression.ReasonMatched,
+			leafReason(expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: "1"}))
+		assert.Equal(GinkgoT(), expression.ReasonMatched,
+			leafReason(expression.ExprCell{OP: operator.Any, Field: "biz.id"}))
+		assert.Equal(GinkgoT(), expression.ReasonNotMatched,
+			leafReason(expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: "2"}))
+		assert.Equal(GinkgoT(), expression.ReasonNotMatched,
+			leafReason(expression.ExprCell{OP: operator.In, Field: "host.tags", Value: []string{"c"}}))
+
+		// missing attribute
+		assert.Equal(GinkgoT(), expression.ReasonMissingAttribute,
+			leafReason(expression.ExprCell{OP: operator.Eq, Field: "host.owner", Value: "admin"}))
+		assert.Equal(GinkgoT(), expression.ReasonMissingAttribute,
+			leafReason(expression.ExprCell{OP: operator.Eq, Field: "biz.id", Value: "1"}))
+
+		// shape mismatch
+		assert.Equal(GinkgoT(), expression.ReasonShapeMismatch,
+			leafReason(expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: []interface{}{"1"}}))
+		assert.Equal(GinkgoT(), expression.ReasonShapeMismatch,
+			leafReason(expression.ExprCell{OP: operator.In, Field: "host.id", Value: "1"}))
+		assert.Equal(GinkgoT(), expression.ReasonShapeMismatch,
+			leafReason(expression.ExprCell{OP: operator.Contains, Field: "host.id", Value: "1"}))
+
+		// type incomparable
+		assert.Equal(GinkgoT(), expression.ReasonTypeIncomparable,
+			leafReason(expression.ExprCell{OP: operator.Gt, Field: "host.level", Value: "1"}))
+		assert.Equal(GinkgoT(), expression.ReasonTypeIncomparable,
+			leafReason(expression.ExprCell{OP: operator.Eq, Field: "host.level", Value: "3"}))
+		assert.Equal(GinkgoT(), expression.ReasonTypeIncomparable,
+			leafReason(expression.ExprCell{OP: operator.StartsWith, Field: "host.level", Value: "3"}))
+		assert.Equal(GinkgoT(), expression.ReasonTypeIncomparable,
+			leafReason(expression.ExprCell{OP: operator.In, Field: "host.level", Value: []interface{}{"3"}}))
+		assert.Equal(GinkgoT(), expression.ReasonTypeIncomparable,
+			leafReason(expression.ExprCell{OP: operator.NotStartsWith, Field: "host.level", Value: "3"}))
+		// the numbers of different types are comparable
+		assert.Equal(GinkgoT(), expression.ReasonNotMatched,
+			leafReason(expression.ExprCell{OP: operator.Gt, Field: "host.level", Value: 3.5}))
+
+		// unsupported operator and no policy
+		assert.Equal(GinkgoT(), expression.ReasonUnsupportedOperator,
+			leafReason(expression.ExprCell{OP: "regex", Field: "host.id", Value: ".*"}))
+		assert.Equal(GinkgoT(), expression.ReasonNoPolicy, leafReason(expression.ExprCell{}))
+	})
+
+	It("short-circuit", func() {
+		e := expression.ExprCell{
+			OP: operator.OR,
+			Content: []expression.ExprCell{
+				{OP: operator.Eq, Field: "host.id", Value: "2"},
+				{
+					OP: operator.AND,
+					Content: []expression.ExprCell{
+						{OP: operator.StartsWith, Field: "host.name", Value: "web"},
+						{OP: operator.Contains, Field: "host.tags", Value: "a"},
+					},
+				},
+				{
+					OP: operator.AND,
+					Content: []expression.ExprCell{
+						{OP: operator.Eq, Field: "biz.id", Value: "1"},
+					},
+				},
+			},
+		}
+
+		allowed, trace := e.EvalWithTrace(o)
+		assert.True(GinkgoT(), allowed)
+		assert.Equal(GinkgoT(), expression.ReasonMatched, trace.Reason)
+		assert.Len(GinkgoT(), trace.Children, 3)
+		assert.Equal(GinkgoT(), expression.ReasonNotMatched, trace.Children[0].Reason)
+		assert.Equal(GinkgoT(), "1", trace.Children[0].ObjectValue)
+		assert.Equal(GinkgoT(), expression.ReasonMatched, trace.Children[1].Reason)
+		assert.Equal(GinkgoT(), expression.ReasonSkipped, trace.Children[2].Reason)
+		assert.Equal(GinkgoT(), expression.ReasonSkipped, trace.Children[2].Children[0].Reason)
+
+		assert.Equal(GinkgoT(), `OR => true (matched)
+  (host.id eq 2) object value: 1 => false (not_matched)
+  AND => true (matched)
+    (host.name starts_with web) object value: web-1 => true (matched)
+    (host.tags contains a) object value: [a b] => true (matched)
+  AND => skipped
+    (biz.id eq 1) => skipped
+`, trace.String())
+
+		data, err := trace.JSON()
+		assert.NoError(GinkgoT(), err)
+		assert.JSONEq(GinkgoT(), `{
+			"op": "OR", "result": true, "reason": "matched",
+			"children": [
+				{"op": "eq", "field": "host.id", "object_value": "1", "policy_value": "2", "result": false, "reason": "not_matched"},
+				{"op": "AND", "result": true, "reason": "matched", "children": [
+					{"op": "starts_with", "field": "host.name", "object_value": "web-1", "policy_value": "web", "result": true, "reason": "matched"},
+					{"op": "contains", "field": "host.tags", "object_value": ["a", "b"], "policy_value": "a", "result": true, "reason": "matched"}
+				]},
+				{"op": "AND", "result": false, "reason": "skipped", "children": [
+					{"op": "eq", "field": "biz.id", "policy_value": "1", "result": false, "reason": "skipped"}
+				]}
+			]
+		}`, string(data))
+	})
+
+	It("empty AND/OR", func() {
+		allowed, trace := (&expression.ExprCell{OP: operator.AND}).EvalWithTrace(o)
+		assert.True(GinkgoT(), allowed)
+		assert.Equal(GinkgoT(), expression.ReasonMatched, trace.Reason)
+
+		allowed, trace = (&expression.ExprCell{OP: operator.OR}).EvalWithTrace(o)
+		assert.False(GinkgoT(), allowed)
+		assert.Equal(GinkgoT(), expression.ReasonNotMatched, trace.Reason)
+	})
+
+	It("same as Eval", func() {
+		r := rand.New(rand.NewSource(1))
+		fields := []string{"host.id", "host.tags", "biz.id"}
+
+		var randExpr func(depth int) expression.ExprCell
+		randExpr = func(depth int) expression.ExprCell {
+			if depth > 0 && r.Intn(3) > 0 {
+				op := operator.AND
+				if r.Intn(2) == 0 {
+					op = operator.OR
+				}
+				content := []expression.ExprCell{}
+				for n := r.Intn(4); n > 0; n-- {
+					content = append(content, randExpr(depth-1))
+				}
+				return expression.ExprCell{OP: op, Content: content}
+			}
+			return expression.ExprCell{
+				OP:    allOperators[r.Intn(len(allOperators))],
+				Field: fields[r.Intn(len(fields))],
+				Value: compileTestValues[r.Intn(len(compileTestValues))],
+			}
+		}
+
+		var checkTrace func(t *expression.Trace)
+		checkTrace = func(t *expression.Trace) {
+			switch t.Reason {
+			case expression.ReasonMatched:
+				assert.True(GinkgoT(), t.Result)
+			case expression.ReasonSkipped:
+			default:
+				assert.False(GinkgoT(), t.Result)
+			}
+			for _, c := range t.Children {
+				checkTrace(c)
+			}
+		}
+
+		for n := 0; n < 1000; n++ {
+			e := randExpr(3)
+
+			data := expression.NewObjectSet()
+			data.Set("host", map[string]interface{}{
+				"id":   compileTestValues[r.Intn(len(compileTestValues))],
+				"tags": compileTestValues[r.Intn(len(compileTestValues))],
+			})
+
+			allowed, trace := e.EvalWithTrace(data)
+			assert.Equal(GinkgoT(), e.Eval(data), allowed, e.String())
+			checkTrace(trace)
+			assert.NotEmpty(GinkgoT(), trace.String())
+		}
+	})
+})
@@ -12,20 +12,23 @@
 package iam
 
 import (
+	"context"
+	"crypto/subtle"
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/http"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/TencentBlueKing/gopkg/stringx"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/source"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/mitchellh/mapstructure"
 
 	"github.com/TencentBlueKing/iam-go-sdk/cache"
 	"github.com/TencentBlueKing/iam-go-sdk/client"
-	"github.com/TencentBlueKing/iam-go-sdk/expression"
 	"github.com/TencentBlueKing/iam-go-sdk/iammigrate"
 	"github.com/TencentBlueKing/iam-go-sdk/logger"
 	"github.com/golang-migrate/migrate/v4"
@@ -40,7 +43,16 @@ type IAM struct {
 	appSecret  string
 	bkTenantID string
 
-	client client.IAMBackendClient
+	client client.ExtendedClient
+
+	clientOpts []client.Option
+
+	tokenCacheTTL time.Duration
+	tokenCache    *tokenCache
+
+	serverSideAuth bool
+
+	batchConcurrency int
 }
 
 type Option func(*IAM)
@@ -51,6 +63,69 @@ func WithBkTenantID(bkTenantID string) Option {
 	}
 }
 
+// WithHTTPClient set the http client to call iam backend, default is a shared keep-alive http client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(i *IAM) {
+		i.clientOpts = append(i.clientOpts, client.WithHTTPClient(httpClient))
+	}
+}
+
+// WithTransport set the http.RoundTripper to call iam backend
+func WithTransport(transport http.RoundTripper) Option {
+	return func(i *IAM) {
+		i.clientOpts = append(i.clientOpts, client.WithTransport(transport))
+	}
+}
+
+// WithAuthStrategy set the authentication strategy of the iam backend calls, will override the default one of
+// NewAPIGatewayIAM/NewDirectIAM
+func WithAuthStrategy(auth client.AuthStrategy) Option {
+	return func(i *IAM) {
+		i.clientOpts = append(i.clientOpts, client.WithAuthStrategy(auth))
+	}
+}
+
+// WithCircuitBreaker enable the circuit breaker of the iam backend calls, fail fast with client.ErrCircuitOpen
+func WithCircuitBreaker(config client.CircuitBreakerConfig) Option {
+	return func(i *IAM) {
+		i.clientOpts = append(i.clientOpts, client.WithCircuitBreaker(config))
+	}
+}
+
+// WithRetryPolicy set the retry policy of the iam backend calls without side effect, e.g. policy query
+func WithRetryPolicy(policy client.RetryPolicy) Option {
+	return func(i *IAM) {
+		i.clientOpts = append(i.clientOpts, client.WithRetryPolicy(policy))
+	}
+}
+
+// WithTokenCacheTTL set the ttl of the cached system token used by IsBasicAuthAllowed, default is 5 minutes;
+// the cached token is used until 2 * ttl if the refresh fails; the cache will be disabled if ttl <= 0
+func WithTokenCacheTTL(ttl time.Duration) Option {
+	return func(i *IAM) {
+		i.tokenCacheTTL = ttl
+	}
+}
+
+// WithServerSideAuth let iam backend make the decision of IsAllowed/BatchIsAllowed/ResourceMultiActionsAllowed/
+// BatchResourceMultiActionsAllowed by the policy auth apis, instead of querying the expression and evaluating locally;
+// use it if some attributes of the resources are only known by iam, or the expressions are too large
+func WithServerSideAuth(enabled bool) Option {
+	return func(i *IAM) {
+		i.serverSideAuth = enabled
+	}
+}
+
+// WithBatchConcurrency set the max number of goroutines to evaluate the resources in the batch methods,
+// e.g. BatchIsAllowed/BatchResourceMultiActionsAllowed, default is runtime.GOMAXPROCS(0);
+// it also limits the concurrent auth_by_actions calls of BatchResourceMultiActionsAllowed with WithServerSideAuth;
+// the resources will be evaluated serially if n <= 1
+func WithBatchConcurrency(n int) Option {
+	return func(i *IAM) {
+		i.batchConcurrency = n
+	}
+}
+
 // NewIAM will create an IAM instance
 func NewIAM(system, appCode, appSecret, bkAPIGatewayURL string, opts ...Option) *IAM {
 	return NewAPIGatewayIAM(system, appCode, appSecret, bkAPIGatewayURL, opts...)
@@ -59,26 +134,65 @@ func NewIAM(system, appCode, appSecret, bkAPIGatewayURL string, opts ...Option)
 // NewAPIGatewayIAM will create an IAM instance, call all api through APIGateway
 // if your TencentBlueking has a APIGateway, use this, recommend
 func NewAPIGatewayIAM(system, appCode, appSecret, bkAPIGatewayURL string, opts ...Option) *IAM {
+	auth := client.APIGatewayAuth{AppCode: appCode, AppSecret: appSecret}
+	return newIAM(system, appCode, appSecret, bkAPIGatewayURL, auth, opts...)
+}
+
+// NewDirectIAM will create an IAM instance, call all api of iam backend directly(without APIGateway),
+// authenticated by X-Bk-App-Code/X-Bk-App-Secret headers
+// NOTE: the apis of iam saas(e.g. GetApplyURL) are not available in this mode
+func NewDirectIAM(system, appCode, appSecret, bkIAMHost string, opts ...Option) *IAM {
+	auth := client.DirectAuth{AppCode: appCode, AppSecret: appSecret}
+	return newIAM(system, appCode, appSecret, bkIAMHost, auth, opts...)
+}
+
+// NewWithClient will create an IAM instance with the iam backend client, e.g. client.NewMemoryClient() for unit tests
+// NOTE: the options of the backend client(e.g. WithHTTPClient/WithRetryPolicy) are not used
+func NewWithClient(cli client.IAMBackendClient, opts ...Option) *IAM {
 	c := &IAM{
-		appCode:   appCode,
-		appSecret: appSecret,
+		tokenCacheTTL:    defaultTokenCacheTTL,
+		batchConcurrency: runtime.GOMAXPROCS(0),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	var clientOpts []client.Option
+	c.client = client.Extend(cli)
+	c.tokenCache = newTokenCache(c.tokenCacheTTL, c.client.GetTokenCtx)
+
+	return c
+}
+
+func newIAM(system, appCode, appSecret, host string, auth client.AuthStrategy, opts ...Option) *IAM {
+	c := &IAM{
+		appCode:          appCode,
+		appSecret:        appSecret,
+		tokenCacheTTL:    defaultTokenCacheTTL,
+		batchConcurrency: runtime.GOMAXPROCS(0),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	clientOpts := append([]client.Option{client.WithAuthStrategy(auth)}, c.clientOpts...)
 	if c.bkTenantID != "" {
 		clientOpts = append(clientOpts, client.WithBkTenantID(c.bkTenantID))
 	}
-	c.client = client.NewIAMBackendClient(bkAPIGatewayURL, system, appCode, appSecret, clientOpts...)
+	c.client = client.Extend(client.NewIAMBackendClient(host, system, appCode, appSecret, clientOpts...))
+	c.tokenCache = newTokenCache(c.tokenCacheTTL, c.client.GetTokenCtx)
 
 	return c
 }
 
 // IsAllowed will check if the permission is allowed
 func (i *IAM) IsAllowed(request Request) (allowed bool, err error) {
+	return i.IsAllowedCtx(context.Background(), request)
+}
+
+// IsAllowedCtx will check if the permission is allowed, the ctx will be passed to the policy query
+func (i *IAM) IsAllowedCtx(ctx context.Context, request Request) (allowed bool, err error) {
 	logger.Debug("calling IAM.is_allowed(request)......")
 
 	// 1. validate
@@ -88,21 +202,18 @@ func (i *IAM) IsAllowed(request Request) (allowed bool, err error) {
 		return
 	}
 
+	if i.serverSideAuth {
+		return i.isAllowedByServer(ctx, request)
+	}
+
 	// 2. policy query
 	logger.Debugf("the request: %v", request)
-	data, err := i.client.V2PolicyQuery(request.System, request)
+	data, err := i.client.V2PolicyQueryTypedCtx(ctx, request.System, request)
 	if err != nil {
 		logger.Errorf("do policy query fail! err=%w", err)
 		return
 	}
-	logger.Debugf("the return policies: %#v", data)
-
-	expr := expression.ExprCell{}
-	err = mapstructure.Decode(data, &expr)
-	if err != nil {
-		logger.Errorf("decode policy query data to expr fail! err=%w", err)
-		return
-	}
+	expr := data.ExprCell
 	logger.Debugf("the expr: %#v", expr)
 
 	// 3. make objSet
@@ -119,8 +230,52 @@ func (i *IAM) IsAllowed(request Request) (allowed bool, err error) {
 	return allowed, nil
 }
 
+// ExplainIsAllowed will check if the permission is allowed, and explain how it is evaluated;
+// the policy query will be called with ?debug=true&force=true, for troubleshooting only, do not use in production
+func (i *IAM) ExplainIsAllowed(request Request) (result ExplainResult, err error) {
+	return i.ExplainIsAllowedCtx(context.Background(), request)
+}
+
+// ExplainIsAllowedCtx will check if the permission is allowed with the ctx, and explain how it is evaluated
+func (i *IAM) ExplainIsAllowedCtx(ctx context.Context, request Request) (result ExplainResult, err error) {
+	// 1. validate
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+
+	// 2. policy query with debug
+	queryBegin := time.Now()
+	data, debug, err := i.client.V2PolicyQueryDebugCtx(ctx, request.System, request)
+	if err != nil {
+		err = fmt.Errorf("do policy query fail: %w", err)
+		return
+	}
+	result.QueryTook = time.Since(queryBegin)
+	result.Debug = debug
+	result.Expr = data.ExprCell
+
+	// 3. make objSet
+	objSet := request.GenObjectSet()
+
+	// 4. eval
+	evalBegin := time.Now()
+	result.Allowed = result.Expr.Eval(objSet)
+	result.EvalTook = time.Since(evalBegin)
+
+	result.ExprString = result.Expr.String()
+	result.ExprRendered = result.Expr.Render(objSet)
+
+	return result, nil
+}
+
 // IsAllowedWithCache will check if the permission is allowed, will cache with ttl
 func (i *IAM) IsAllowedWithCache(request Request, ttl time.Duration) (allowed bool, err error) {
+	return i.IsAllowedWithCacheCtx(context.Background(), request, ttl)
+}
+
+// IsAllowedWithCacheCtx will check if the permission is allowed with the ctx, will cache with ttl
+func (i *IAM) IsAllowedWithCacheCtx(ctx context.Context, request Request, ttl time.Duration) (allowed bool, err error) {
 	var k string
 	k, err = request.CacheKey()
 	if err != nil {
@@ -132,7 +287,7 @@ func (i *IAM) IsAllowedWithCache(request Request, ttl time.Duration) (allowed bo
 		return value.(bool), nil
 	}
 
-	allowed, err = i.IsAllowed(request)
+	allowed, err = i.IsAllowedCtx(ctx, request)
 	if err != nil {
 		return
 	}
@@ -143,40 +298,24 @@ func (i *IAM) IsAllowedWithCache(request Request, ttl time.Duration) (allowed bo
 
 // BatchIsAllowed will batch check the permission for resources lists
 func (i *IAM) BatchIsAllowed(request Request, resourcesList []Resources) (result map[string]bool, err error) {
-	// logger.debug("calling IAM.is_allowed(request)......")
-
-	// 1. validate
-	err = request.Validate()
-	if err != nil {
-		return
-	}
-
-	// 2. policy query without resources
-	if len(request.Resources) != 0 {
-		request.Resources = Resources{}
-	}
-
-	data, err := i.client.V2PolicyQuery(request.System, request)
-	if err != nil {
-		return
-	}
+	return i.BatchIsAllowedCtx(context.Background(), request, resourcesList)
+}
 
-	expr := expression.ExprCell{}
-	err = mapstructure.Decode(data, &expr)
+// BatchIsAllowedCtx will batch check the permission for resources lists with the ctx
+func (i *IAM) BatchIsAllowedCtx(
+	ctx context.Context,
+	request Request,
+	resourcesList []Resources,
+) (result map[string]bool, err error) {
+	list, err := i.BatchIsAllowedListCtx(ctx, request, resourcesList)
 	if err != nil {
 		return
 	}
 
-	result = make(map[string]bool, len(resourcesList))
-	for _, resources := range resourcesList {
-		// 3. make objSet
-		objSet := NewObjectSet(resources)
-
-		// 4. eval
-		allowed := expr.Eval(objSet)
-		result[i.buildResourceID(resources)] = allowed
+	result = make(map[string]bool, len(list))
+	for _, r := range list {
+		result[r.ResourceID] = r.Allowed
 	}
-
 	return result, nil
 }
 
@@ -195,20 +334,32 @@ func (i *IAM) buildResourceID(resources Resources) string {
 
 // ResourceMultiActionsAllowed will check the permission of one-resource with multi-actions
 func (i *IAM) ResourceMultiActionsAllowed(request MultiActionRequest) (result map[string]bool, err error) {
+	return i.ResourceMultiActionsAllowedCtx(context.Background(), request)
+}
+
+// ResourceMultiActionsAllowedCtx will check the permission of one-resource with multi-actions with the ctx
+func (i *IAM) ResourceMultiActionsAllowedCtx(
+	ctx context.Context,
+	request MultiActionRequest,
+) (result map[string]bool, err error) {
 	// 1. validate
 	err = request.Validate()
 	if err != nil {
 		return
 	}
 
+	if i.serverSideAuth {
+		return i.resourceMultiActionsAllowedByServer(ctx, request)
+	}
+
 	// 2. batch action policy query
 	logger.Debugf("the request: %v", request)
-	data, err := i.client.V2PolicyQueryByActions(request.System, request)
+	actionPolicies, err := i.client.V2PolicyQueryByActionsTypedCtx(ctx, request.System, request)
 	if err != nil {
 		logger.Errorf("do policy query by actions fail! err=%w", err)
 		return
 	}
-	logger.Debugf("the return policies of actions: %#v", data)
+	logger.Debugf("the return policies of actions: %#v", actionPolicies)
 
 	result = make(map[string]bool, len(request.Actions))
 
@@ -216,12 +367,6 @@ func (i *IAM) ResourceMultiActionsAllowed(request MultiActionRequest) (result ma
 	objSet := NewObjectSet(request.Resources)
 
 	// 4. calculate perms
-	var actionPolicies []ActionPolicy
-	err = mapstructure.Decode(data, &actionPolicies)
-	if err != nil {
-		logger.Errorf("decode policy query by actions data to expr fail! err=%w", err)
-		return
-	}
 	for _, actionPolicy := range actionPolicies {
 		allowed := actionPolicy.Condition.Eval(objSet)
 		result[actionPolicy.Action.ID] = allowed
@@ -234,69 +379,65 @@ func (i *IAM) BatchResourceMultiActionsAllowed(
 	request MultiActionRequest,
 	resourcesList []Resources,
 ) (results map[string]map[string]bool, err error) {
-	// 1. validate
-	err = request.Validate()
-	if err != nil {
-		return
-	}
-
-	// 2. policy query without resources
-	if len(request.Resources) != 0 {
-		request.Resources = Resources{}
-	}
+	return i.BatchResourceMultiActionsAllowedCtx(context.Background(), request, resourcesList)
+}
 
-	// 3. batch action policy query
-	logger.Debugf("the request: %v", request)
-	data, err := i.client.V2PolicyQueryByActions(request.System, request)
+// BatchResourceMultiActionsAllowedCtx will check the permissions of batch-resource with multi-actions with the ctx
+func (i *IAM) BatchResourceMultiActionsAllowedCtx(
+	ctx context.Context,
+	request MultiActionRequest,
+	resourcesList []Resources,
+) (results map[string]map[string]bool, err error) {
+	list, err := i.BatchResourceMultiActionsAllowedListCtx(ctx, request, resourcesList)
 	if err != nil {
-		logger.Errorf("do policy query by actions fail! err=%w", err)
 		return
 	}
-	logger.Debugf("the return policies of actions: %#v", data)
-
-	results = make(map[string]map[string]bool, len(resourcesList))
-
-	for _, resources := range resourcesList {
-		result := make(map[string]bool, len(request.Actions))
 
-		// 4. make objSet
-		objSet := NewObjectSet(resources)
-
-		// 5. calculate perms
-		var actionPolicies []ActionPolicy
-		err = mapstructure.Decode(data, &actionPolicies)
-		if err != nil {
-			logger.Errorf("decode policy query by actions data to expr fail! err=%w", err)
-			return
-		}
-		for _, actionPolicy := range actionPolicies {
-			allowed := actionPolicy.Condition.Eval(objSet)
-			result[actionPolicy.Action.ID] = allowed
-		}
-		results[i.buildResourceID(resources)] = result
+	results = make(map[string]map[string]bool, len(list))
+	for _, r := range list {
+		results[r.ResourceID] = r.Actions
 	}
-	return
+	return results, nil
 }
 
 // GetToken will get the token of system
 func (i *IAM) GetToken() (token string, err error) {
-	return i.client.GetToken()
+	return i.GetTokenCtx(context.Background())
+}
+
+// GetTokenCtx will get the token of system with the ctx
+func (i *IAM) GetTokenCtx(ctx context.Context) (token string, err error) {
+	return i.client.GetTokenCtx(ctx)
 }
 
 // IsBasicAuthAllowed will check basic auth of callback request
+// the system token is cached, and will be refreshed once if the password not match, in case of the token rotated
 func (i *IAM) IsBasicAuthAllowed(username, password string) (err error) {
+	return i.IsBasicAuthAllowedCtx(context.Background(), username, password)
+}
+
+// IsBasicAuthAllowedCtx will check basic auth of callback request with the ctx
+func (i *IAM) IsBasicAuthAllowedCtx(ctx context.Context, username, password string) (err error) {
 	if username != "bk_iam" {
 		err = errors.New("username is not bk_iam")
 		return
 	}
 
-	token, err := i.client.GetToken()
+	token, err := i.tokenCache.get(ctx)
 	if err != nil {
 		err = fmt.Errorf("get system token fail: %w", err)
 		return
 	}
 
-	if password != token {
+	if !isTokenEqual(password, token) {
+		token, err = i.tokenCache.forceRefresh(ctx, token)
+		if err != nil {
+			err = fmt.Errorf("refresh system token fail: %w", err)
+			return
+		}
+	}
+
+	if !isTokenEqual(password, token) {
 		err = fmt.Errorf("password in basic_auth not equals to system token [password=%s***, token=%s***]",
 			stringx.Truncate(password, 6), stringx.Truncate(token, 6))
 		return
@@ -305,14 +446,23 @@ func (i *IAM) IsBasicAuthAllowed(username, password string) (err error) {
 	return nil
 }
 
+func isTokenEqual(password, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), []byte(token)) == 1
+}
+
 // GetApplyURL will generate the application URL
 func (i *IAM) GetApplyURL(application Application) (url string, err error) {
+	return i.GetApplyURLCtx(context.Background(), application)
+}
+
+// GetApplyURLCtx will generate the application URL with the ctx
+func (i *IAM) GetApplyURLCtx(ctx context.Context, application Application) (url string, err error) {
 	err = application.Validate()
 	if err != nil {
 		return
 	}
 
-	url, err = i.client.GetApplyURL(application)
+	url, err = i.client.GetApplyURLCtx(ctx, application)
 
 	return
 }
@@ -373,14 +523,30 @@ func (i *IAM) Migrate(db *sql.DB, sourceDriver source.Driver, migrateTable strin
 	}
 
 	// run migrations
-	return mig.Up()
+	err = mig.Up()
+
+	return unwrapDatabaseError(err)
 }
 
-// TODO:
-// - grant_resource_creator_actions
-// - grant_batch_resource_creator_actions
-// - grant_or_revoke_instance_permission
-// - grant_or_revoke_path_permission
-// - batch_grant_or_revoke_instance_permission
-// - batch_grant_or_revoke_path_permission
-// - query_polices_with_action_id
+// unwrapDatabaseError keeps the iam backend error of database.Error for errors.Is/errors.As,
+// database.Error does not support unwrap; the line and the query of the failed migration are kept in the message
+func unwrapDatabaseError(err error) error {
+	var dbErr database.Error
+	var dbErrPtr *database.Error
+	switch {
+	case errors.As(err, &dbErrPtr) && dbErrPtr != nil:
+		dbErr = *dbErrPtr
+	case errors.As(err, &dbErr):
+	default:
+		return err
+	}
+	if dbErr.OrigErr == nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("line %d, query %q", dbErr.Line, dbErr.Query)
+	if dbErr.Err != "" {
+		msg = fmt.Sprintf("%s (%s)", dbErr.Err, msg)
+	}
+	return fmt.Errorf("%s: %w", msg, dbErr.OrigErr)
+}
@@ -12,9 +12,19 @@
 package iam
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/stretchr/testify/assert"
 
 	. "github.com/onsi/ginkgo"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
 )
 
 var _ = Describe("iam", func() {
@@ -59,4 +69,84 @@ var _ = Describe("iam", func() {
 			assert.Equal(GinkgoT(), resourceID, "type,id/type2,id2")
 		})
 	})
+
+	Context("iam.ExplainIsAllowed", func() {
+		var ts *httptest.Server
+
+		BeforeEach(func() {
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok",
+					"data": {"op": "eq", "field": "app.id", "value": "1"},
+					"debug": {"expression": "app.id eq 1"}}`))
+			}))
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("ok", func() {
+			iam := NewDirectIAM("bk_paas", "bk_paas", "{app_secret}", ts.URL)
+			request := NewRequest("bk_paas", NewSubject("user", "admin"), NewAction("develop_app"), []ResourceNode{
+				NewResourceNode("bk_paas", "app", "2", map[string]interface{}{}),
+			})
+
+			result, err := iam.ExplainIsAllowed(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), result.Allowed)
+			assert.Equal(GinkgoT(), map[string]interface{}{"expression": "app.id eq 1"}, result.Debug)
+			assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "1"}, result.Expr)
+			assert.Equal(GinkgoT(), "(app.id eq 1)", result.ExprString)
+			assert.Equal(GinkgoT(), "(2 eq 1)", result.ExprRendered)
+			assert.Greater(GinkgoT(), result.QueryTook, time.Duration(0))
+		})
+	})
+
+	Context("unwrapDatabaseError", func() {
+		apiErr := &client.APIError{StatusCode: http.StatusConflict, Code: 1902409, Message: "action already exists"}
+
+		It("keep the line and the query", func() {
+			err := unwrapDatabaseError(database.Error{
+				Line: 2, Query: []byte("add_action"), Err: "migration failed", OrigErr: apiErr,
+			})
+			assert.EqualError(GinkgoT(), err,
+				`migration failed (line 2, query "add_action"): `+apiErr.Error())
+			assert.ErrorIs(GinkgoT(), err, client.ErrConflict)
+
+			err = unwrapDatabaseError(&database.Error{Query: []byte("select 1"), OrigErr: apiErr})
+			assert.EqualError(GinkgoT(), err, `line 0, query "select 1": `+apiErr.Error())
+			assert.ErrorIs(GinkgoT(), err, client.ErrConflict)
+		})
+
+		It("other errors", func() {
+			errFail := errors.New("fail")
+			assert.Equal(GinkgoT(), errFail, unwrapDatabaseError(errFail))
+			assert.NoError(GinkgoT(), unwrapDatabaseError(nil))
+		})
+	})
+
+	Context("NewWithClient", func() {
+		It("ok", func() {
+			cli := client.NewMemoryClient()
+			cli.Grant("bk_paas", "user", "admin", "develop_app",
+				expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "1"})
+			iam := NewWithClient(cli)
+
+			request := NewRequest("bk_paas", NewSubject("user", "admin"), NewAction("develop_app"), []ResourceNode{
+				NewResourceNode("bk_paas", "app", "1", map[string]interface{}{}),
+			})
+			allowed, err := iam.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), allowed)
+
+			request.Resources[0].ID = "2"
+			allowed, err = iam.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+
+			assert.NoError(GinkgoT(), iam.IsBasicAuthAllowed("bk_iam", client.MemoryToken))
+		})
+	})
 })
@@ -21,7 +21,6 @@ import (
 
 	"github.com/TencentBlueKing/iam-go-sdk/client"
 	"github.com/TencentBlueKing/iam-go-sdk/util"
-	"github.com/mitchellh/mapstructure"
 )
 
 // DoMigate is a Go function that performs the migration.
@@ -34,13 +33,13 @@ func DoMigate(ctx context.Context, cli client.IAMBackendClient, data []byte, tem
 	version int) error {
 	// ping
 	if err := cli.Ping(); err != nil {
-		return fmt.Errorf("iam service is not available: %s", err.Error())
+		return fmt.Errorf("iam service is not available: %w", err)
 	}
 
 	// format migration file, fill template variables
 	data, err := FormatData(data, templateVar)
 	if err != nil {
-		return fmt.Errorf("format data error: %s", err.Error())
+		return fmt.Errorf("format data error: %w", err)
 	}
 
 	var migrations Migrations
@@ -53,9 +52,9 @@ func DoMigate(ctx context.Context, cli client.IAMBackendClient, data []byte, tem
 	}
 
 	// get current model
-	models, err := queryAllModels(cli, migrations.SystemID)
+	models, err := queryAllModels(ctx, cli, migrations.SystemID)
 	if err != nil && version != 0 {
-		return fmt.Errorf("query all models fail, %s", err.Error())
+		return fmt.Errorf("query all models fail, %w", err)
 	}
 
 	// do migrate
@@ -75,7 +74,7 @@ func DoMigate(ctx context.Context, cli client.IAMBackendClient, data []byte, tem
 			return fmt.Errorf("operation %s data is invalid", v.Operation)
 		}
 		if err = migrateFuncs[v.Operation](ctx, migrations.SystemID, cli, opData, models); err != nil {
-			return fmt.Errorf("do migrate [%s] fail, %s", v.Operation, err.Error())
+			return fmt.Errorf("do migrate [%s] fail, %w", v.Operation, err)
 		}
 	}
 	return nil
@@ -100,14 +99,9 @@ func FormatData(data []byte, templateVar interface{}) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
-func queryAllModels(cli client.IAMBackendClient, systemID string) (ModelIDs, error) {
+func queryAllModels(ctx context.Context, cli client.IAMBackendClient, systemID string) (ModelIDs, error) {
 	var models ModelIDs
-	var model Model
-	result, err := cli.ModelQuery(systemID)
-	if err != nil {
-		return models, err
-	}
-	err = mapstructure.Decode(result, &model)
+	model, err := client.Extend(cli).ModelQueryTypedCtx(ctx, systemID)
 	if err != nil {
 		return models, err
 	}
@@ -12,9 +12,14 @@
 package iammigrate
 
 import (
+	"context"
+	"os"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
 )
 
 var _ = Describe("Migrations", func() {
@@ -73,4 +78,39 @@ var _ = Describe("Migrations", func() {
 
 	})
 
+	Describe("DoMigate", func() {
+		var data []byte
+
+		BeforeEach(func() {
+			var err error
+			data, err = os.ReadFile("testdata/0000_init.up.json")
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("add", func() {
+			cli := client.NewMemoryClient()
+
+			err := DoMigate(context.Background(), cli, data, map[string]interface{}{"SYSTEM_ID": "demo"}, 0)
+			assert.NoError(GinkgoT(), err)
+
+			mutations := cli.Mutations()
+			assert.Equal(GinkgoT(), "AddSystem", mutations[0].Operation)
+			assert.Equal(GinkgoT(), "AddAction", mutations[1].Operation)
+			assert.Equal(GinkgoT(), []string{"access_developer_center"}, mutations[1].IDs)
+			assert.Equal(GinkgoT(), "AddResourceType", mutations[2].Operation)
+		})
+
+		It("update the registered models", func() {
+			cli := client.NewMemoryClient()
+			cli.RegisterModel("demo", []string{"app"}, nil, []string{"access_developer_center"})
+
+			err := DoMigate(context.Background(), cli, data, map[string]interface{}{"SYSTEM_ID": "demo"}, 1)
+			assert.NoError(GinkgoT(), err)
+
+			mutations := cli.Mutations()
+			assert.Equal(GinkgoT(), "UpdateSystem", mutations[0].Operation)
+			assert.Equal(GinkgoT(), "UpdateAction", mutations[1].Operation)
+			assert.Equal(GinkgoT(), "UpdateResourceType", mutations[2].Operation)
+		})
+	})
 })
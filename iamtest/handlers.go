@@ -0,0 +1,393 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iamtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// the model types, key is the path segment, value is the key in model query response
+var modelTypes = map[string]string{
+	"resource-types":      "resource_types",
+	"instance-selections": "instance_selections",
+	"actions":             "actions",
+}
+
+type systemModel struct {
+	baseInfo map[string]interface{}
+	items    map[string][]map[string]interface{}
+	configs  map[string]interface{}
+}
+
+type policyQueryRequest struct {
+	System  string `json:"system"`
+	Subject struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"subject"`
+	Action struct {
+		ID string `json:"id"`
+	} `json:"action"`
+	Actions []struct {
+		ID string `json:"id"`
+	} `json:"actions"`
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, body []byte) {
+	path := r.URL.Path
+	if path == "/ping" {
+		_, _ = w.Write([]byte("pong"))
+		return
+	}
+
+	if path == "/api/v1/open/application/" && r.Method == http.MethodPost {
+		s.handleApplyURL(w)
+		return
+	}
+
+	if strings.HasPrefix(path, "/api/v2/policy/systems/") {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/v2/policy/systems/"), "/"), "/")
+		if len(parts) == 2 && r.Method == http.MethodPost {
+			switch parts[1] {
+			case "query":
+				s.handlePolicyQuery(w, parts[0], body)
+				return
+			case "query_by_actions":
+				s.handlePolicyQueryByActions(w, parts[0], body)
+				return
+			}
+		}
+	}
+
+	if strings.HasPrefix(path, "/api/v1/model/systems") {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/v1/model/systems"), "/"), "/")
+		if s.routeModel(w, r.Method, parts, body) {
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "iamtest: api %s %s not found", r.Method, path)
+}
+
+// routeModel handles the model apis, return false if not matched
+func (s *Server) routeModel(w http.ResponseWriter, method string, parts []string, body []byte) bool {
+	switch {
+	case len(parts) == 1 && parts[0] == "" && method == http.MethodPost:
+		s.handleAddSystem(w, body)
+	case len(parts) == 1 && method == http.MethodPut:
+		s.handleUpdateSystem(w, parts[0], body)
+	case len(parts) == 2 && parts[1] == "token" && method == http.MethodGet:
+		s.handleToken(w, parts[0])
+	case len(parts) == 2 && parts[1] == "query" && method == http.MethodGet:
+		s.handleModelQuery(w, parts[0])
+	case len(parts) == 2 && modelTypes[parts[1]] != "" && method == http.MethodPost:
+		s.handleAddModelItems(w, parts[0], modelTypes[parts[1]], body)
+	case len(parts) == 2 && modelTypes[parts[1]] != "" && method == http.MethodDelete:
+		s.handleDeleteModelItems(w, parts[0], modelTypes[parts[1]], body)
+	case len(parts) == 3 && modelTypes[parts[1]] != "" && method == http.MethodPut:
+		s.handleUpdateModelItem(w, parts[0], modelTypes[parts[1]], parts[2], body)
+	case len(parts) == 3 && parts[1] == "configs" && (method == http.MethodPost || method == http.MethodPut):
+		s.handleConfig(w, parts[0], parts[2], body)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, system string) {
+	s.mu.Lock()
+	token, ok := s.tokens[system]
+	s.mu.Unlock()
+
+	if !ok {
+		token = DefaultToken
+	}
+	writeOK(w, map[string]interface{}{"token": token})
+}
+
+func (s *Server) handleApplyURL(w http.ResponseWriter) {
+	s.mu.Lock()
+	url := s.applyURL
+	s.mu.Unlock()
+
+	if url == "" {
+		url = s.URL + "/apply/"
+	}
+	writeOK(w, map[string]interface{}{"url": url})
+}
+
+// queryExpr returns the expression of the subject and action, nil if no policy
+func (s *Server) queryExpr(system, subjectType, subjectID, actionID string) *expression.ExprCell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exprs := []expression.ExprCell{}
+	for _, p := range s.policies {
+		if p.system == system && p.subjectType == subjectType && p.subjectID == subjectID && p.actionID == actionID {
+			exprs = append(exprs, p.expr)
+		}
+	}
+
+	switch len(exprs) {
+	case 0:
+		return nil
+	case 1:
+		return &exprs[0]
+	default:
+		return &expression.ExprCell{OP: operator.OR, Content: exprs}
+	}
+}
+
+func (s *Server) handlePolicyQuery(w http.ResponseWriter, system string, body []byte) {
+	var req policyQueryRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid body: %s", err)
+		return
+	}
+
+	expr := s.queryExpr(system, req.Subject.Type, req.Subject.ID, req.Action.ID)
+	if expr == nil {
+		writeOK(w, nil)
+		return
+	}
+	writeOK(w, expr)
+}
+
+func (s *Server) handlePolicyQueryByActions(w http.ResponseWriter, system string, body []byte) {
+	var req policyQueryRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid body: %s", err)
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		var condition interface{} = map[string]interface{}{}
+		if expr := s.queryExpr(system, req.Subject.Type, req.Subject.ID, action.ID); expr != nil {
+			condition = expr
+		}
+		data = append(data, map[string]interface{}{
+			"action":    map[string]interface{}{"id": action.ID},
+			"condition": condition,
+		})
+	}
+	writeOK(w, data)
+}
+
+// decodeItems decodes the body into a list of model items, the single item will be wrapped into a list
+func decodeItems(body []byte) ([]map[string]interface{}, error) {
+	var items []map[string]interface{}
+	if err := json.Unmarshal(body, &items); err == nil {
+		return items, nil
+	}
+
+	var item map[string]interface{}
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, err
+	}
+	return []map[string]interface{}{item}, nil
+}
+
+func itemID(item map[string]interface{}) string {
+	id, _ := item["id"].(string)
+	return id
+}
+
+func indexOfItem(items []map[string]interface{}, id string) int {
+	for i, item := range items {
+		if itemID(item) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) handleAddSystem(w http.ResponseWriter, body []byte) {
+	var system map[string]interface{}
+	if err := json.Unmarshal(body, &system); err != nil || itemID(system) == "" {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid system")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := itemID(system)
+	if _, ok := s.models[id]; ok {
+		writeError(w, http.StatusConflict, "iamtest: system %s already exists", id)
+		return
+	}
+	s.models[id] = &systemModel{
+		baseInfo: system,
+		items:    map[string][]map[string]interface{}{},
+		configs:  map[string]interface{}{},
+	}
+	writeOK(w, map[string]interface{}{"id": id})
+}
+
+func (s *Server) handleUpdateSystem(w http.ResponseWriter, system string, body []byte) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid system")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[system]
+	if !ok {
+		writeError(w, http.StatusNotFound, "iamtest: system %s not found", system)
+		return
+	}
+	for k, v := range data {
+		model.baseInfo[k] = v
+	}
+	writeOK(w, nil)
+}
+
+func (s *Server) handleModelQuery(w http.ResponseWriter, system string) {
+	data := s.Model(system)
+	if data == nil {
+		writeError(w, http.StatusNotFound, "iamtest: system %s not found", system)
+		return
+	}
+	writeOK(w, data)
+}
+
+// Model returns the model of the system registered by the model apis, in the format of the model query response;
+// return nil if the system not exists
+func (s *Server) Model(system string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[system]
+	if !ok {
+		return nil
+	}
+
+	data := map[string]interface{}{"base_info": model.baseInfo}
+	for _, key := range modelTypes {
+		items := model.items[key]
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		data[key] = items
+	}
+	for name, config := range model.configs {
+		data[name] = config
+	}
+	return data
+}
+
+func (s *Server) handleAddModelItems(w http.ResponseWriter, system, key string, body []byte) {
+	items, err := decodeItems(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid body: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[system]
+	if !ok {
+		writeError(w, http.StatusNotFound, "iamtest: system %s not found", system)
+		return
+	}
+	for _, item := range items {
+		id := itemID(item)
+		if id == "" {
+			writeError(w, http.StatusBadRequest, "iamtest: %s id is empty", key)
+			return
+		}
+		if indexOfItem(model.items[key], id) != -1 {
+			writeError(w, http.StatusConflict, "iamtest: %s %s already exists", key, id)
+			return
+		}
+	}
+	model.items[key] = append(model.items[key], items...)
+	writeOK(w, nil)
+}
+
+func (s *Server) handleUpdateModelItem(w http.ResponseWriter, system, key, id string, body []byte) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid body: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[system]
+	if !ok {
+		writeError(w, http.StatusNotFound, "iamtest: system %s not found", system)
+		return
+	}
+	idx := indexOfItem(model.items[key], id)
+	if idx == -1 {
+		writeError(w, http.StatusNotFound, "iamtest: %s %s not found", key, id)
+		return
+	}
+	for k, v := range data {
+		model.items[key][idx][k] = v
+	}
+	writeOK(w, nil)
+}
+
+func (s *Server) handleDeleteModelItems(w http.ResponseWriter, system, key string, body []byte) {
+	items, err := decodeItems(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid body: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[system]
+	if !ok {
+		writeError(w, http.StatusNotFound, "iamtest: system %s not found", system)
+		return
+	}
+	for _, item := range items {
+		if idx := indexOfItem(model.items[key], itemID(item)); idx != -1 {
+			model.items[key] = append(model.items[key][:idx], model.items[key][idx+1:]...)
+		}
+	}
+	writeOK(w, nil)
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, system, name string, body []byte) {
+	var config interface{}
+	if err := json.Unmarshal(body, &config); err != nil {
+		writeError(w, http.StatusBadRequest, "iamtest: invalid body: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[system]
+	if !ok {
+		writeError(w, http.StatusNotFound, "iamtest: system %s not found", system)
+		return
+	}
+	model.configs[name] = config
+	writeOK(w, nil)
+}
@@ -0,0 +1,313 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+// Package iamtest provides a fake iam backend http server for testing, all the data are stored in memory.
+//
+//	s := iamtest.NewServer()
+//	defer s.Close()
+//
+//	s.AddPolicy("demo", "user", "admin", "edit", expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "1"})
+//	i := iam.NewDirectIAM("demo", "demo", "secret", s.URL)
+package iamtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+// DefaultToken is the system token returned by the server if not set by SetToken
+const DefaultToken = "iamtest-token"
+
+// the iam backend error codes are 1901xxx, the last 3 digits are the http status code
+const iamCodePrefix = 1901000
+
+// Request is the request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Decode decodes the json body of the request into v
+func (r Request) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Fault is the fault injected into the requests of a path
+type Fault struct {
+	// StatusCode is the http status code of the response, default is 200 if Code is set
+	StatusCode int
+	// Code is the body.code of the response, default is 1901000 + StatusCode
+	Code    int
+	Message string
+	// RawBody is the response body as it is, e.g. the non-json 502 page from APIGateway
+	RawBody string
+	// Latency delays the response
+	Latency time.Duration
+	// Times is the number of the requests affected, 0 means all
+	Times int
+}
+
+func (f *Fault) isError() bool {
+	return f.StatusCode != 0 || f.Code != 0 || f.RawBody != ""
+}
+
+// TestingT is the interface of *testing.T and GinkgoT() used by the assert helpers
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type policy struct {
+	system      string
+	subjectType string
+	subjectID   string
+	actionID    string
+	expr        expression.ExprCell
+}
+
+// Server is the fake iam backend, implements the apis called by client.IAMBackendClient
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	tokens   map[string]string
+	applyURL string
+	policies []policy
+	models   map[string]*systemModel
+	faults   map[string]*Fault
+	requests []Request
+}
+
+// NewServer starts a fake iam backend, the caller should call Close when finished
+func NewServer() *Server {
+	s := &Server{
+		tokens: map[string]string{},
+		models: map[string]*systemModel{},
+		faults: map[string]*Fault{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetToken set the token of the system
+func (s *Server) SetToken(system, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[system] = token
+}
+
+// SetApplyURL set the url returned by the application api, default is {server.URL}/apply/
+func (s *Server) SetApplyURL(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applyURL = url
+}
+
+// AddPolicy grants the subject the action with the expression,
+// multiple policies of the same subject and action will be combined with OR
+func (s *Server) AddPolicy(system, subjectType, subjectID, actionID string, expr expression.ExprCell) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies = append(s.policies, policy{
+		system:      system,
+		subjectType: subjectType,
+		subjectID:   subjectID,
+		actionID:    actionID,
+		expr:        expr,
+	})
+}
+
+// AllowAll grants the subject the action without any condition
+func (s *Server) AllowAll(system, subjectType, subjectID, actionID string) {
+	s.AddPolicy(system, subjectType, subjectID, actionID, expression.ExprCell{OP: operator.Any, Value: []interface{}{}})
+}
+
+// ClearPolicies removes all the policies
+func (s *Server) ClearPolicies() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies = nil
+}
+
+// InjectFault injects the fault into the requests of the path, the empty path means all paths
+func (s *Server) InjectFault(path string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = &fault
+}
+
+// InjectError makes the requests of the path fail with the http status code
+func (s *Server) InjectError(path string, statusCode int) {
+	s.InjectFault(path, Fault{StatusCode: statusCode})
+}
+
+// InjectLatency delays the responses of the path
+func (s *Server) InjectLatency(path string, latency time.Duration) {
+	s.InjectFault(path, Fault{Latency: latency})
+}
+
+// ClearFaults removes all the injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = map[string]*Fault{}
+}
+
+// Requests returns all the received requests
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the received requests of the method and path
+func (s *Server) RequestsTo(method, path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := []Request{}
+	for _, r := range s.requests {
+		if r.Method == method && r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// ResetRequests removes all the received requests
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// AssertCalled asserts the method and path has been called `times` times, times < 0 means at least once
+func (s *Server) AssertCalled(t TestingT, method, path string, times int) bool {
+	n := len(s.RequestsTo(method, path))
+	if (times < 0 && n == 0) || (times >= 0 && n != times) {
+		t.Errorf("iamtest: expected %s %s to be called %d times, but called %d times", method, path, times, n)
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled asserts the method and path has not been called
+func (s *Server) AssertNotCalled(t TestingT, method, path string) bool {
+	return s.AssertCalled(t, method, path, 0)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	fault := s.takeFault(r.URL.Path)
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.isError() {
+			writeFault(w, fault)
+			return
+		}
+	}
+
+	s.route(w, r, body)
+}
+
+// takeFault returns the fault of the path and decreases the remaining times, must be called with the lock
+func (s *Server) takeFault(path string) *Fault {
+	key := path
+	fault, ok := s.faults[key]
+	if !ok {
+		key = ""
+		fault, ok = s.faults[key]
+	}
+	if !ok {
+		return nil
+	}
+
+	f := *fault
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(s.faults, key)
+		}
+	}
+	return &f
+}
+
+func writeFault(w http.ResponseWriter, f *Fault) {
+	status := f.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if f.RawBody != "" {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, f.RawBody)
+		return
+	}
+
+	code := f.Code
+	if code == 0 {
+		code = iamCodePrefix + status
+	}
+	message := f.Message
+	if message == "" {
+		message = fmt.Sprintf("iamtest: injected fault %s", http.StatusText(status))
+	}
+	writeJSON(w, status, code, message, nil)
+}
+
+func writeJSON(w http.ResponseWriter, status, code int, message string, data interface{}) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    code,
+		"message": message,
+		"data":    data,
+	})
+}
+
+func writeOK(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, 0, "ok", data)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, iamCodePrefix+status, fmt.Sprintf(format, args...), nil)
+}
@@ -0,0 +1,198 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iamtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk"
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+	"github.com/TencentBlueKing/iam-go-sdk/iammigrate"
+	"github.com/TencentBlueKing/iam-go-sdk/iamtest"
+)
+
+const queryPath = "/api/v2/policy/systems/demo/query/"
+
+var _ = Describe("Server", func() {
+	var s *iamtest.Server
+	var i *iam.IAM
+
+	request := iam.NewRequest("demo", iam.NewSubject("user", "admin"), iam.NewAction("edit"), []iam.ResourceNode{
+		iam.NewResourceNode("demo", "app", "1", map[string]interface{}{}),
+	})
+
+	BeforeEach(func() {
+		s = iamtest.NewServer()
+		i = iam.NewDirectIAM("demo", "demo", "secret", s.URL)
+	})
+
+	AfterEach(func() {
+		s.Close()
+	})
+
+	Context("policy", func() {
+		It("no policy", func() {
+			allowed, err := i.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+		})
+
+		It("seeded policies", func() {
+			s.AddPolicy("demo", "user", "admin", "edit", expression.ExprCell{
+				OP: operator.Eq, Field: "app.id", Value: "2",
+			})
+			allowed, err := i.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+
+			s.AddPolicy("demo", "user", "admin", "edit", expression.ExprCell{
+				OP: operator.In, Field: "app.id", Value: []interface{}{"1", "3"},
+			})
+			allowed, err = i.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), allowed)
+
+			s.ClearPolicies()
+			allowed, err = i.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+		})
+
+		It("query by actions", func() {
+			s.AllowAll("demo", "user", "admin", "view")
+
+			multiReq := iam.NewMultiActionRequest("demo", iam.NewSubject("user", "admin"),
+				[]iam.Action{iam.NewAction("edit"), iam.NewAction("view")}, request.Resources)
+			result, err := i.ResourceMultiActionsAllowed(multiReq)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]bool{"edit": false, "view": true}, result)
+		})
+	})
+
+	Context("token and apply url", func() {
+		It("token", func() {
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", iamtest.DefaultToken))
+
+			s.SetToken("demo", "abc")
+			token, err := i.GetToken()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "abc", token)
+		})
+
+		It("apply url", func() {
+			s.SetApplyURL("http://iam.example.com/apply")
+			i := iam.NewAPIGatewayIAM("demo", "demo", "secret", s.URL)
+
+			url, err := i.GetApplyURL(iam.NewApplication("demo", []iam.ApplicationAction{
+				iam.NewApplicationAction("edit", []iam.ApplicationRelatedResourceType{}),
+			}))
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "http://iam.example.com/apply", url)
+		})
+	})
+
+	Context("model", func() {
+		It("migrate", func() {
+			data, err := os.ReadFile("../iammigrate/testdata/0000_init.up.json")
+			assert.NoError(GinkgoT(), err)
+			cli := client.NewIAMBackendClient(s.URL, "demo", "demo", "secret")
+
+			err = iammigrate.DoMigate(context.Background(), cli, data, map[string]interface{}{"SYSTEM_ID": "demo"}, 0)
+			assert.NoError(GinkgoT(), err)
+
+			model := s.Model("demo")
+			assert.Equal(GinkgoT(), "Demo平台", model["base_info"].(map[string]interface{})["name"])
+			assert.Len(GinkgoT(), model["resource_types"], 1)
+			assert.NotEmpty(GinkgoT(), model["actions"])
+
+			// run again, upsert will update the existing models
+			err = iammigrate.DoMigate(context.Background(), cli, data, map[string]interface{}{"SYSTEM_ID": "demo"}, 1)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), s.Model("demo")["resource_types"], 1)
+
+			err = cli.BatchDeleteResourceType("demo", "app")
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), s.Model("demo")["resource_types"], 0)
+		})
+
+		It("not found and conflict", func() {
+			cli := client.NewIAMBackendClient(s.URL, "demo", "demo", "secret")
+
+			_, err := cli.ModelQuery("demo")
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+
+			assert.NoError(GinkgoT(), cli.AddSystem(map[string]interface{}{"id": "demo"}))
+			err = cli.AddSystem(map[string]interface{}{"id": "demo"})
+			assert.True(GinkgoT(), errors.Is(err, client.ErrConflict))
+		})
+	})
+
+	Context("fault", func() {
+		It("error", func() {
+			s.InjectFault(queryPath, iamtest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+
+			_, err := i.IsAllowed(request)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrServerError))
+
+			_, err = i.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("error code", func() {
+			s.InjectFault("", iamtest.Fault{Code: 1901401, Message: "app_secret invalid"})
+
+			_, err := i.IsAllowed(request)
+			assert.True(GinkgoT(), errors.Is(err, client.ErrUnauthorized))
+
+			s.ClearFaults()
+			_, err = i.IsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("latency", func() {
+			s.InjectLatency(queryPath, 200*time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := i.IsAllowedCtx(ctx, request)
+			assert.True(GinkgoT(), errors.Is(err, context.DeadlineExceeded))
+		})
+	})
+
+	Context("requests", func() {
+		It("assert", func() {
+			_, _ = i.IsAllowed(request)
+
+			s.AssertCalled(GinkgoT(), http.MethodPost, queryPath, 1)
+			s.AssertNotCalled(GinkgoT(), http.MethodGet, "/ping")
+
+			requests := s.RequestsTo(http.MethodPost, queryPath)
+			assert.Equal(GinkgoT(), "demo", requests[0].Header.Get("X-Bk-App-Code"))
+
+			var body iam.Request
+			assert.NoError(GinkgoT(), requests[0].Decode(&body))
+			assert.Equal(GinkgoT(), request, body)
+
+			s.ResetRequests()
+			assert.Empty(GinkgoT(), s.Requests())
+		})
+	})
+})
@@ -0,0 +1,172 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+	"github.com/TencentBlueKing/iam-go-sdk/logger"
+)
+
+const idFieldSuffix = ".id"
+
+// GetAuthorizedInstances will get the instances the subject is authorized for the action,
+// the policies are reduced to the instance ids and the _bk_iam_path_ prefixes of each resource type
+func (i *IAM) GetAuthorizedInstances(request Request) (AuthorizedInstances, error) {
+	return i.GetAuthorizedInstancesCtx(context.Background(), request)
+}
+
+// GetAuthorizedInstancesCtx will get the instances the subject is authorized for the action with the ctx
+func (i *IAM) GetAuthorizedInstancesCtx(ctx context.Context, request Request) (result AuthorizedInstances, err error) {
+	// 1. validate
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+
+	// 2. policy query without resources
+	if len(request.Resources) != 0 {
+		request.Resources = Resources{}
+	}
+
+	data, err := i.client.V2PolicyQueryTypedCtx(ctx, request.System, request)
+	if err != nil {
+		err = fmt.Errorf("do policy query fail: %w", err)
+		return
+	}
+	logger.Debugf("the expr: %s", data.String())
+
+	// 3. reduce
+	result = newAuthorizedInstances()
+	if data.OP == "" {
+		// no policy
+		return result, nil
+	}
+
+	r := instancesReducer{result: &result, seen: map[string]struct{}{}}
+	if r.reduce(data.ExprCell) {
+		result = newAuthorizedInstances()
+		result.Any = true
+	}
+	return result, nil
+}
+
+func newAuthorizedInstances() AuthorizedInstances {
+	return AuthorizedInstances{
+		IDs:       map[string][]string{},
+		Paths:     map[string][]string{},
+		Remaining: []expression.ExprCell{},
+	}
+}
+
+type instancesReducer struct {
+	result *AuthorizedInstances
+	// the seen ids and paths, `ids:type:id` or `paths:type:path`
+	seen map[string]struct{}
+}
+
+// reduce will reduce the expression into the result, return true if any
+func (r *instancesReducer) reduce(e expression.ExprCell) bool {
+	switch e.OP {
+	case operator.Any:
+		return true
+	case operator.OR:
+		for _, c := range e.Content {
+			if r.reduce(c) {
+				return true
+			}
+		}
+		return false
+	case operator.AND:
+		// the AND with only one expression is the expression itself
+		if len(e.Content) == 1 {
+			return r.reduce(e.Content[0])
+		}
+	case operator.Eq:
+		if resourceType, ok := trimFieldSuffix(e.Field, idFieldSuffix); ok {
+			if id, ok := e.Value.(string); ok {
+				r.add(r.result.IDs, "ids", resourceType, id)
+				return false
+			}
+		}
+	case operator.In:
+		if resourceType, ok := trimFieldSuffix(e.Field, idFieldSuffix); ok {
+			if ids, ok := toStrings(e.Value); ok {
+				for _, id := range ids {
+					r.add(r.result.IDs, "ids", resourceType, id)
+				}
+				return false
+			}
+		}
+	case operator.StartsWith:
+		if resourceType, ok := trimFieldSuffix(e.Field, expression.KeywordBKIAMPathFieldSuffix); ok {
+			if path, ok := e.Value.(string); ok {
+				// same as the eval, starts with from `/a,1/b,*/` to `/a,1/b,`
+				if strings.HasSuffix(path, ",*/") {
+					path = strings.TrimSuffix(path, "*/")
+				}
+				r.add(r.result.Paths, "paths", resourceType, path)
+				return false
+			}
+		}
+	}
+
+	r.result.Remaining = append(r.result.Remaining, e)
+	return false
+}
+
+func (r *instancesReducer) add(values map[string][]string, kind, resourceType, value string) {
+	key := kind + ":" + resourceType + ":" + value
+	if _, ok := r.seen[key]; ok {
+		return
+	}
+	r.seen[key] = struct{}{}
+
+	values[resourceType] = append(values[resourceType], value)
+}
+
+// trimFieldSuffix will return the resource type of the field `type{suffix}`
+func trimFieldSuffix(field, suffix string) (string, bool) {
+	if !strings.HasSuffix(field, suffix) {
+		return "", false
+	}
+
+	resourceType := strings.TrimSuffix(field, suffix)
+	if resourceType == "" || strings.Contains(resourceType, ".") {
+		return "", false
+	}
+	return resourceType, true
+}
+
+// toStrings will convert the value to []string, return false if the value is not an array of strings
+func toStrings(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
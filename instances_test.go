@@ -0,0 +1,143 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("GetAuthorizedInstances", func() {
+	var cli *client.MemoryClient
+	var i *IAM
+	request := NewRequest("bk_cmdb", NewSubject("user", "admin"), NewAction("view_host"), nil)
+
+	BeforeEach(func() {
+		cli = client.NewMemoryClient()
+		i = NewWithClient(cli)
+	})
+
+	It("ids, paths and remaining", func() {
+		ownerExpr := expression.ExprCell{OP: operator.Eq, Field: "host.owner", Value: "admin"}
+		andExpr := expression.ExprCell{
+			OP: operator.AND,
+			Content: []expression.ExprCell{
+				{OP: operator.Eq, Field: "host.id", Value: "5"},
+				{OP: operator.Eq, Field: "host.owner", Value: "admin"},
+			},
+		}
+
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: "1"})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.In, Field: "host.id", Value: []interface{}{"1", "2"}})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.AND, Content: []expression.ExprCell{
+				{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,2/"},
+			}})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.In, Field: "module.id", Value: []interface{}{"10"}})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host", ownerExpr)
+		cli.Grant("bk_cmdb", "user", "admin", "view_host", andExpr)
+
+		result, err := i.GetAuthorizedInstances(request)
+
+		assert.NoError(GinkgoT(), err)
+		assert.False(GinkgoT(), result.Any)
+		assert.Equal(GinkgoT(), map[string][]string{"host": {"1", "2"}, "module": {"10"}}, result.IDs)
+		assert.Equal(GinkgoT(), map[string][]string{"host": {"/biz,1/set,", "/biz,2/"}}, result.Paths)
+		assert.Len(GinkgoT(), result.Remaining, 2)
+		assert.Equal(GinkgoT(), operator.Eq, result.Remaining[0].OP)
+		assert.Equal(GinkgoT(), "host.owner", result.Remaining[0].Field)
+		assert.Equal(GinkgoT(), operator.AND, result.Remaining[1].OP)
+	})
+
+	It("not reducible values", func() {
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: []interface{}{"1"}})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.In, Field: "host.id", Value: []interface{}{"1", 2}})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.StartsWith, Field: "host.name", Value: "web"})
+
+		result, err := i.GetAuthorizedInstances(request)
+
+		assert.NoError(GinkgoT(), err)
+		assert.Empty(GinkgoT(), result.IDs)
+		assert.Empty(GinkgoT(), result.Paths)
+		assert.Len(GinkgoT(), result.Remaining, 3)
+	})
+
+	It("any", func() {
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.Eq, Field: "host.id", Value: "1"})
+		cli.GrantAny("bk_cmdb", "user", "admin", "view_host")
+
+		result, err := i.GetAuthorizedInstances(request)
+
+		assert.NoError(GinkgoT(), err)
+		assert.True(GinkgoT(), result.Any)
+		assert.Empty(GinkgoT(), result.IDs)
+		assert.Empty(GinkgoT(), result.Paths)
+		assert.Empty(GinkgoT(), result.Remaining)
+	})
+
+	It("no policy", func() {
+		result, err := i.GetAuthorizedInstances(request)
+
+		assert.NoError(GinkgoT(), err)
+		assert.False(GinkgoT(), result.Any)
+		assert.Empty(GinkgoT(), result.IDs)
+		assert.Empty(GinkgoT(), result.Paths)
+		assert.Empty(GinkgoT(), result.Remaining)
+	})
+
+	It("same as eval", func() {
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.In, Field: "host.id", Value: []interface{}{"1", "2"}})
+		cli.Grant("bk_cmdb", "user", "admin", "view_host",
+			expression.ExprCell{OP: operator.StartsWith, Field: "host._bk_iam_path_", Value: "/biz,1/set,*/"})
+
+		result, err := i.GetAuthorizedInstances(request)
+		assert.NoError(GinkgoT(), err)
+
+		resourcesList := []Resources{
+			{NewResourceNode("bk_cmdb", "host", "1", map[string]interface{}{})},
+			{NewResourceNode("bk_cmdb", "host", "3", map[string]interface{}{"_bk_iam_path_": "/biz,1/set,2/"})},
+			{NewResourceNode("bk_cmdb", "host", "4", map[string]interface{}{"_bk_iam_path_": "/biz,1/"})},
+		}
+		expected, err := i.BatchIsAllowed(request, resourcesList)
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), map[string]bool{"1": true, "3": true, "4": false}, expected)
+
+		for _, resources := range resourcesList {
+			node := resources[0]
+			allowed := false
+			for _, id := range result.IDs[node.Type] {
+				allowed = allowed || id == node.ID
+			}
+			for _, path := range result.Paths[node.Type] {
+				p, _ := node.Attribute["_bk_iam_path_"].(string)
+				allowed = allowed || strings.HasPrefix(p, path)
+			}
+			assert.Equal(GinkgoT(), expected[node.ID], allowed, node.ID)
+		}
+	})
+})
@@ -29,10 +29,42 @@ var (
 	},
 		[]string{"method", "path", "status", "component"},
 	)
+
+	// ClientRequestAttemptDuration 依赖 api 每次尝试(包括重试)的响应时间分布, attempt 为第几次尝试(从 1 开始);
+	// 没有响应(如连接失败, 超时)时 status 为 error
+	ClientRequestAttemptDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "client_request_attempt_duration_milliseconds",
+		Help:        "How long it took to process each attempt of the request, partitioned by status code, method, HTTP path and attempt.",
+		ConstLabels: prometheus.Labels{"service": serviceName},
+		Buckets:     []float64{20, 50, 100, 200, 500, 1000, 2000, 5000},
+	},
+		[]string{"method", "path", "status", "component", "attempt"},
+	)
+
+	// ClientCircuitBreakerState 依赖 api 熔断器当前状态, 0: closed, 1: open, 2: half-open; route 为接口路径模板
+	ClientCircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "client_circuit_breaker_state",
+		Help:        "The current state of the circuit breaker, 0: closed, 1: open, 2: half-open.",
+		ConstLabels: prometheus.Labels{"service": serviceName},
+	},
+		[]string{"route", "component"},
+	)
+
+	// ClientCircuitBreakerStateChanges 依赖 api 熔断器状态变更次数
+	ClientCircuitBreakerStateChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "client_circuit_breaker_state_changes_total",
+		Help:        "How many times the circuit breaker state changed, partitioned by route and the new state.",
+		ConstLabels: prometheus.Labels{"service": serviceName},
+	},
+		[]string{"route", "state", "component"},
+	)
 )
 
 // RegisterMetrics will register the mtrics
 func RegisterMetrics() {
 	// Register the summary and the histogram with Prometheus's default registry.
 	prometheus.MustRegister(ClientRequestDuration)
+	prometheus.MustRegister(ClientRequestAttemptDuration)
+	prometheus.MustRegister(ClientCircuitBreakerState)
+	prometheus.MustRegister(ClientCircuitBreakerStateChanges)
 }
@@ -27,7 +27,7 @@ func NewIAMBasicAuth(i *iam.IAM) func(next http.Handler) http.Handler {
 				return
 			}
 
-			err := i.IsBasicAuthAllowed(username, password)
+			err := i.IsBasicAuthAllowedCtx(r.Context(), username, password)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusForbidden)
 				return
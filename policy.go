@@ -0,0 +1,255 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+)
+
+const (
+	defaultPolicyPageSize = 100
+	// the max count of policy ids per PolicySubjects call, the ids are passed by the query string
+	policySubjectsBatchSize = 100
+)
+
+// QueryPoliciesWithActionID will query one page of the policies of the action
+func (i *IAM) QueryPoliciesWithActionID(action Action, opts QueryPoliciesOptions) (PolicyPage, error) {
+	return i.QueryPoliciesWithActionIDCtx(context.Background(), action, opts)
+}
+
+// QueryPoliciesWithActionIDCtx will query one page of the policies of the action with the ctx
+func (i *IAM) QueryPoliciesWithActionIDCtx(
+	ctx context.Context,
+	action Action,
+	opts QueryPoliciesOptions,
+) (page PolicyPage, err error) {
+	if action.ID == "" {
+		err = errors.New("the action id should not be empty")
+		return
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPolicyPageSize
+	}
+
+	body := map[string]interface{}{
+		"action_id": action.ID,
+		"page":      opts.Page,
+		"page_size": opts.PageSize,
+	}
+	if opts.Timestamp > 0 {
+		body["timestamp"] = opts.Timestamp
+	}
+
+	resp, err := i.client.PolicyListTypedCtx(ctx, body)
+	if err != nil {
+		err = fmt.Errorf("query policies of action %s fail: %w", action.ID, err)
+		return
+	}
+
+	// the policies in the list response have no action
+	results := make([]Policy, 0, len(resp.Results))
+	for _, p := range resp.Results {
+		policy := newPolicy(p)
+		policy.Action = action
+		results = append(results, policy)
+	}
+
+	return PolicyPage{
+		Count:     resp.Count,
+		Timestamp: resp.Metadata.Timestamp,
+		Results:   results,
+	}, nil
+}
+
+// GetPolicy will get the policy by id
+func (i *IAM) GetPolicy(policyID int64) (Policy, error) {
+	return i.GetPolicyCtx(context.Background(), policyID)
+}
+
+// GetPolicyCtx will get the policy by id with the ctx
+func (i *IAM) GetPolicyCtx(ctx context.Context, policyID int64) (policy Policy, err error) {
+	p, err := i.client.PolicyGetTypedCtx(ctx, policyID)
+	if err != nil {
+		err = fmt.Errorf("get policy %d fail: %w", policyID, err)
+		return
+	}
+	return newPolicy(p), nil
+}
+
+// newPolicy converts the policy of the iam backend response
+func newPolicy(p client.Policy) Policy {
+	return Policy{
+		ID:        p.ID,
+		Version:   p.Version,
+		Action:    NewAction(p.Action.ID),
+		Subject:   PolicySubject(p.Subject),
+		Expr:      p.Expression,
+		ExpiredAt: p.ExpiredAt,
+	}
+}
+
+// PolicyIterator pages through all the policies of an action
+//
+//	it := i.IteratePoliciesWithActionID(iam.NewAction("edit"), iam.QueryPoliciesOptions{})
+//	for it.Next(ctx) {
+//		policy := it.Policy()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type PolicyIterator struct {
+	iam    *IAM
+	action Action
+	opts   QueryPoliciesOptions
+
+	policies []Policy
+	index    int
+	policy   Policy
+	fetched  int64
+	done     bool
+	err      error
+}
+
+// IteratePoliciesWithActionID will create an iterator of all the policies of the action, start from opts.Page;
+// all the pages are queried with the timestamp of the first page, so the results are consistent
+func (i *IAM) IteratePoliciesWithActionID(action Action, opts QueryPoliciesOptions) *PolicyIterator {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPolicyPageSize
+	}
+	return &PolicyIterator{
+		iam:    i,
+		action: action,
+		opts:   opts,
+	}
+}
+
+// Next will move to the next policy, fetch the next page if needed;
+// return false if no more policies or error occurred, check Err() for the error
+func (it *PolicyIterator) Next(ctx context.Context) bool {
+	for it.index >= len(it.policies) {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		page, err := it.iam.QueryPoliciesWithActionIDCtx(ctx, it.action, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		if it.opts.Timestamp <= 0 {
+			it.opts.Timestamp = page.Timestamp
+		}
+		it.opts.Page++
+		it.fetched += int64(len(page.Results))
+		it.policies = page.Results
+		it.index = 0
+
+		if int64(len(page.Results)) < it.opts.PageSize || it.fetched >= page.Count {
+			it.done = true
+		}
+	}
+
+	it.policy = it.policies[it.index]
+	it.index++
+	return true
+}
+
+// Policy returns the current policy
+func (it *PolicyIterator) Policy() Policy {
+	return it.policy
+}
+
+// Err returns the error occurred during the iteration
+func (it *PolicyIterator) Err() error {
+	return it.err
+}
+
+// ListAllowedSubjects will list the subjects allowed to perform the action on the resources,
+// all the policies of the action are evaluated locally
+func (i *IAM) ListAllowedSubjects(action Action, resources []ResourceNode) ([]AllowedSubject, error) {
+	return i.ListAllowedSubjectsCtx(context.Background(), action, resources)
+}
+
+// ListAllowedSubjectsCtx will list the subjects allowed to perform the action on the resources with the ctx
+func (i *IAM) ListAllowedSubjectsCtx(
+	ctx context.Context,
+	action Action,
+	resources []ResourceNode,
+) ([]AllowedSubject, error) {
+	// 1. fetch and eval all the policies of the action
+	objSet := NewObjectSet(resources)
+
+	matched := []Policy{}
+	it := i.IteratePoliciesWithActionID(action, QueryPoliciesOptions{})
+	for it.Next(ctx) {
+		policy := it.Policy()
+		if policy.Expr.Eval(objSet) {
+			matched = append(matched, policy)
+		}
+	}
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+
+	// 2. resolve the subjects of the matched policies
+	subjects := make(map[int64]PolicySubject, len(matched))
+	for start := 0; start < len(matched); start += policySubjectsBatchSize {
+		end := start + policySubjectsBatchSize
+		if end > len(matched) {
+			end = len(matched)
+		}
+
+		ids := make([]int64, 0, end-start)
+		for _, policy := range matched[start:end] {
+			ids = append(ids, policy.ID)
+		}
+
+		items, err := i.client.PolicySubjectsTypedCtx(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("query the subjects of policies fail: %w", err)
+		}
+		for _, item := range items {
+			subjects[item.ID] = PolicySubject(item.Subject)
+		}
+	}
+
+	// 3. de-duplicate by subject, keep the first granting policy
+	allowed := []AllowedSubject{}
+	seen := map[Subject]struct{}{}
+	for _, policy := range matched {
+		subject, ok := subjects[policy.ID]
+		if !ok {
+			// the policy is deleted after listed
+			continue
+		}
+
+		key := subject.Subject()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		allowed = append(allowed, AllowedSubject{Subject: subject, Policy: policy})
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,200 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+)
+
+var _ = Describe("policy", func() {
+	var cli *client.MemoryClient
+	var i *IAM
+
+	BeforeEach(func() {
+		cli = client.NewMemoryClient()
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			cli.Grant("bk_paas", "user", "user"+id, "develop_app",
+				expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: id})
+		}
+		cli.GrantAny("bk_paas", "user", "admin", "manage_app")
+		i = NewWithClient(cli)
+	})
+
+	Context("QueryPoliciesWithActionID", func() {
+		It("ok", func() {
+			page, err := i.QueryPoliciesWithActionID(NewAction("develop_app"), QueryPoliciesOptions{Page: 2, PageSize: 2})
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(5), page.Count)
+			assert.NotZero(GinkgoT(), page.Timestamp)
+			assert.Len(GinkgoT(), page.Results, 2)
+
+			policy := page.Results[0]
+			assert.Equal(GinkgoT(), int64(3), policy.ID)
+			assert.Equal(GinkgoT(), NewAction("develop_app"), policy.Action)
+			assert.Equal(GinkgoT(), PolicySubject{Type: "user", ID: "user3", Name: "user3"}, policy.Subject)
+			assert.Equal(GinkgoT(), NewSubject("user", "user3"), policy.Subject.Subject())
+			assert.Equal(GinkgoT(), expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "3"}, policy.Expr)
+			assert.Equal(GinkgoT(), client.MemoryPolicyExpiredAt, policy.Expiry().Unix())
+		})
+
+		It("empty", func() {
+			page, err := i.QueryPoliciesWithActionID(NewAction("view_app"), QueryPoliciesOptions{})
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(0), page.Count)
+			assert.Equal(GinkgoT(), []Policy{}, page.Results)
+		})
+
+		It("invalid action", func() {
+			_, err := i.QueryPoliciesWithActionID(NewAction(""), QueryPoliciesOptions{})
+
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("decode the api response", func() {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(GinkgoT(), "/api/v1/systems/bk_paas/policies", r.URL.Path)
+				assert.Equal(GinkgoT(), "develop_app", r.URL.Query().Get("action_id"))
+				assert.Equal(GinkgoT(), "1", r.URL.Query().Get("page"))
+				assert.Equal(GinkgoT(), "100", r.URL.Query().Get("page_size"))
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {
+					"metadata": {"system": "bk_paas", "action": {"id": "develop_app"}, "timestamp": 1600000000},
+					"count": 1,
+					"results": [{
+						"version": "1", "id": 10, "subject": {"type": "user", "id": "admin", "name": "Admin"},
+						"expression": {"op": "in", "field": "app.id", "value": ["1", "2"]},
+						"expired_at": 4102444800
+					}]
+				}}`))
+			}))
+			defer ts.Close()
+			i := NewDirectIAM("bk_paas", "bk_paas", "{app_secret}", ts.URL)
+
+			page, err := i.QueryPoliciesWithActionID(NewAction("develop_app"), QueryPoliciesOptions{})
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(1600000000), page.Timestamp)
+			assert.Equal(GinkgoT(), []Policy{{
+				ID:        10,
+				Version:   "1",
+				Action:    NewAction("develop_app"),
+				Subject:   PolicySubject{Type: "user", ID: "admin", Name: "Admin"},
+				Expr:      expression.ExprCell{OP: operator.In, Field: "app.id", Value: []interface{}{"1", "2"}},
+				ExpiredAt: 4102444800,
+			}}, page.Results)
+		})
+	})
+
+	Context("GetPolicy", func() {
+		It("ok", func() {
+			policy, err := i.GetPolicy(6)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), NewAction("manage_app"), policy.Action)
+			assert.Equal(GinkgoT(), operator.Any, policy.Expr.OP)
+		})
+
+		It("not found", func() {
+			_, err := i.GetPolicy(100)
+
+			assert.True(GinkgoT(), errors.Is(err, client.ErrNotFound))
+		})
+	})
+
+	Context("PolicyIterator", func() {
+		It("all pages", func() {
+			it := i.IteratePoliciesWithActionID(NewAction("develop_app"), QueryPoliciesOptions{PageSize: 2})
+
+			ids := []int64{}
+			for it.Next(context.Background()) {
+				ids = append(ids, it.Policy().ID)
+			}
+
+			assert.NoError(GinkgoT(), it.Err())
+			assert.Equal(GinkgoT(), []int64{1, 2, 3, 4, 5}, ids)
+			assert.False(GinkgoT(), it.Next(context.Background()))
+		})
+
+		It("empty", func() {
+			it := i.IteratePoliciesWithActionID(NewAction("view_app"), QueryPoliciesOptions{})
+
+			assert.False(GinkgoT(), it.Next(context.Background()))
+			assert.NoError(GinkgoT(), it.Err())
+		})
+
+		It("error", func() {
+			it := i.IteratePoliciesWithActionID(NewAction(""), QueryPoliciesOptions{})
+
+			assert.False(GinkgoT(), it.Next(context.Background()))
+			assert.Error(GinkgoT(), it.Err())
+		})
+	})
+
+	Context("ListAllowedSubjects", func() {
+		resources := []ResourceNode{NewResourceNode("bk_paas", "app", "3", map[string]interface{}{})}
+
+		It("ok", func() {
+			cli.Grant("bk_paas", "user", "user3", "develop_app",
+				expression.ExprCell{OP: operator.In, Field: "app.id", Value: []interface{}{"1", "3"}})
+			cli.GrantAny("bk_paas", "user", "admin", "develop_app")
+
+			subjects, err := i.ListAllowedSubjects(NewAction("develop_app"), resources)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), subjects, 2)
+			assert.Equal(GinkgoT(), PolicySubject{Type: "user", ID: "user3", Name: "user3"}, subjects[0].Subject)
+			assert.Equal(GinkgoT(), int64(3), subjects[0].Policy.ID)
+			assert.Equal(GinkgoT(), PolicySubject{Type: "user", ID: "admin", Name: "admin"}, subjects[1].Subject)
+			assert.Equal(GinkgoT(), operator.Any, subjects[1].Policy.Expr.OP)
+		})
+
+		It("no subject", func() {
+			subjects, err := i.ListAllowedSubjects(NewAction("develop_app"),
+				[]ResourceNode{NewResourceNode("bk_paas", "app", "10", map[string]interface{}{})})
+
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), subjects)
+		})
+
+		It("many policies", func() {
+			for n := 0; n < 250; n++ {
+				cli.GrantAny("bk_paas", "user", fmt.Sprintf("many%d", n), "view_app")
+			}
+
+			subjects, err := i.ListAllowedSubjects(NewAction("view_app"), resources)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), subjects, 250)
+			assert.Equal(GinkgoT(), "many249", subjects[249].Subject.ID)
+		})
+
+		It("invalid action", func() {
+			_, err := i.ListAllowedSubjects(NewAction(""), resources)
+
+			assert.Error(GinkgoT(), err)
+		})
+	})
+})
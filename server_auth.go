@@ -0,0 +1,119 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TencentBlueKing/iam-go-sdk/logger"
+)
+
+// policyAuthByResourcesRequest is the body of the policy auth by resources api
+type policyAuthByResourcesRequest struct {
+	System        string      `json:"system" mapstructure:"system"`
+	Subject       Subject     `json:"subject" mapstructure:"subject"`
+	Action        Action      `json:"action" mapstructure:"action"`
+	ResourcesList []Resources `json:"resources_list" mapstructure:"resources_list"`
+}
+
+// isAllowedByServer will check the permission by the policy auth api
+func (i *IAM) isAllowedByServer(ctx context.Context, request Request) (allowed bool, err error) {
+	logger.Debugf("the request: %v", request)
+	result, err := i.client.V2PolicyAuthTypedCtx(ctx, request.System, request)
+	if err != nil {
+		logger.Errorf("do policy auth fail! err=%w", err)
+		return
+	}
+	logger.Debugf("the return of policy auth: %#v", result)
+
+	return result.Allowed, nil
+}
+
+// batchIsAllowedByServer will batch check the permission for resources lists by the policy auth by resources api,
+// the key of the response is the resource id built by buildResourceID, return error if any resource is missing
+func (i *IAM) batchIsAllowedByServer(
+	ctx context.Context,
+	request Request,
+	resourcesList []Resources,
+) (result []ResourceAllowed, err error) {
+	body := policyAuthByResourcesRequest{
+		System:        request.System,
+		Subject:       request.Subject,
+		Action:        request.Action,
+		ResourcesList: resourcesList,
+	}
+
+	data, err := i.client.PolicyAuthByResourcesTypedCtx(ctx, body)
+	if err != nil {
+		logger.Errorf("do policy auth by resources fail! err=%w", err)
+		return
+	}
+
+	result = make([]ResourceAllowed, 0, len(resourcesList))
+	for _, resources := range resourcesList {
+		key := i.buildResourceID(resources)
+		allowed, ok := data[key]
+		if !ok {
+			return nil, fmt.Errorf("the resource `%s` is missing in the response of policy auth by resources", key)
+		}
+		result = append(result, ResourceAllowed{ResourceID: key, Allowed: allowed})
+	}
+	return result, nil
+}
+
+// resourceMultiActionsAllowedByServer will check the permission of one-resource with multi-actions
+// by the policy auth by actions api, the actions not in the response are not allowed
+func (i *IAM) resourceMultiActionsAllowedByServer(
+	ctx context.Context,
+	request MultiActionRequest,
+) (result map[string]bool, err error) {
+	logger.Debugf("the request: %v", request)
+	data, err := i.client.PolicyAuthByActionsTypedCtx(ctx, request)
+	if err != nil {
+		logger.Errorf("do policy auth by actions fail! err=%w", err)
+		return
+	}
+	logger.Debugf("the return of policy auth by actions: %#v", data)
+
+	result = make(map[string]bool, len(request.Actions))
+	for _, action := range request.Actions {
+		result[action.ID] = data[action.ID]
+	}
+	return result, nil
+}
+
+// batchResourceMultiActionsAllowedByServer will check the permissions of batch-resource with multi-actions,
+// there is no batch api, so the policy auth by actions api will be called for each resource,
+// the calls are made concurrently, see WithBatchConcurrency, the inflight calls are canceled on the first error
+func (i *IAM) batchResourceMultiActionsAllowedByServer(
+	ctx context.Context,
+	request MultiActionRequest,
+	resourcesList []Resources,
+) (results []ResourceActionsAllowed, err error) {
+	results = make([]ResourceActionsAllowed, len(resourcesList))
+	err = i.runConcurrently(ctx, len(resourcesList), 1, func(ctx context.Context, idx int) error {
+		resourceRequest := request
+		resourceRequest.Resources = resourcesList[idx]
+
+		result, err := i.resourceMultiActionsAllowedByServer(ctx, resourceRequest)
+		if err != nil {
+			return err
+		}
+		results[idx] = ResourceActionsAllowed{ResourceID: i.buildResourceID(resourcesList[idx]), Actions: result}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
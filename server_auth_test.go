@@ -0,0 +1,227 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/iam-go-sdk/client"
+	"github.com/TencentBlueKing/iam-go-sdk/expression"
+	"github.com/TencentBlueKing/iam-go-sdk/expression/operator"
+	"github.com/TencentBlueKing/iam-go-sdk/iamtest"
+)
+
+var _ = Describe("server side auth", func() {
+	subject := NewSubject("user", "admin")
+	resourcesList := []Resources{
+		{NewResourceNode("bk_paas", "app", "1", map[string]interface{}{})},
+		{NewResourceNode("bk_paas", "app", "2", map[string]interface{}{})},
+	}
+
+	Context("same results as local eval", func() {
+		var local, server *IAM
+
+		BeforeEach(func() {
+			cli := client.NewMemoryClient()
+			cli.Grant("bk_paas", "user", "admin", "develop_app",
+				expression.ExprCell{OP: operator.Eq, Field: "app.id", Value: "1"})
+			cli.GrantAny("bk_paas", "user", "admin", "view_app")
+
+			local = NewWithClient(cli)
+			server = NewWithClient(cli, WithServerSideAuth(true))
+		})
+
+		It("IsAllowed", func() {
+			for _, resources := range resourcesList {
+				request := NewRequest("bk_paas", subject, NewAction("develop_app"), resources)
+
+				expected, err := local.IsAllowed(request)
+				assert.NoError(GinkgoT(), err)
+				allowed, err := server.IsAllowed(request)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), expected, allowed)
+			}
+		})
+
+		It("BatchIsAllowed", func() {
+			request := NewRequest("bk_paas", subject, NewAction("develop_app"), nil)
+
+			expected, err := local.BatchIsAllowed(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			result, err := server.BatchIsAllowed(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]bool{"1": true, "2": false}, expected)
+			assert.Equal(GinkgoT(), expected, result)
+		})
+
+		It("ResourceMultiActionsAllowed", func() {
+			request := NewMultiActionRequest("bk_paas", subject,
+				[]Action{NewAction("develop_app"), NewAction("view_app"), NewAction("delete_app")}, resourcesList[1])
+
+			expected, err := local.ResourceMultiActionsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			result, err := server.ResourceMultiActionsAllowed(request)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, result)
+		})
+
+		It("BatchResourceMultiActionsAllowed", func() {
+			request := NewMultiActionRequest("bk_paas", subject,
+				[]Action{NewAction("develop_app"), NewAction("view_app")}, nil)
+
+			expected, err := local.BatchResourceMultiActionsAllowed(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			results, err := server.BatchResourceMultiActionsAllowed(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]map[string]bool{
+				"1": {"develop_app": true, "view_app": true},
+				"2": {"develop_app": false, "view_app": true},
+			}, expected)
+			assert.Equal(GinkgoT(), expected, results)
+		})
+	})
+
+	Context("policy auth apis", func() {
+		var ts *httptest.Server
+		var paths []string
+		var bodies []map[string]interface{}
+		var i *IAM
+
+		BeforeEach(func() {
+			paths = nil
+			bodies = nil
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				var body map[string]interface{}
+				data, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(data, &body)
+				bodies = append(bodies, body)
+
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/api/v2/policy/systems/bk_paas/auth/":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"allowed": true}}`))
+				case "/api/v1/policy/auth_by_resources":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"1": true, "2": false}}`))
+				case "/api/v1/policy/auth_by_actions":
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"develop_app": true}}`))
+				default:
+					_, _ = w.Write([]byte(`{"code": 0, "message": "ok", "data": {"allowed": "true"}}`))
+				}
+			}))
+			i = NewDirectIAM("bk_paas", "bk_paas", "{app_secret}", ts.URL, WithServerSideAuth(true))
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("IsAllowed", func() {
+			allowed, err := i.IsAllowed(NewRequest("bk_paas", subject, NewAction("develop_app"), resourcesList[0]))
+
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), allowed)
+			assert.Equal(GinkgoT(), []string{"/api/v2/policy/systems/bk_paas/auth/"}, paths)
+		})
+
+		It("BatchIsAllowed", func() {
+			result, err := i.BatchIsAllowed(NewRequest("bk_paas", subject, NewAction("develop_app"), nil), resourcesList)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]bool{"1": true, "2": false}, result)
+			assert.Equal(GinkgoT(), []string{"/api/v1/policy/auth_by_resources"}, paths)
+			assert.Len(GinkgoT(), bodies[0]["resources_list"], 2)
+		})
+
+		It("ResourceMultiActionsAllowed", func() {
+			request := NewMultiActionRequest("bk_paas", subject,
+				[]Action{NewAction("develop_app"), NewAction("view_app")}, resourcesList[0])
+
+			result, err := i.ResourceMultiActionsAllowed(request)
+
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]bool{"develop_app": true, "view_app": false}, result)
+			assert.Equal(GinkgoT(), []string{"/api/v1/policy/auth_by_actions"}, paths)
+		})
+
+		It("invalid response", func() {
+			_, err := i.IsAllowed(NewRequest("bk_paas", subject, NewAction("develop_app"), resourcesList[0]))
+			assert.NoError(GinkgoT(), err)
+
+			i := NewDirectIAM("other", "bk_paas", "{app_secret}", ts.URL, WithServerSideAuth(true))
+			_, err = i.IsAllowed(NewRequest("other", subject, NewAction("develop_app"), resourcesList[0]))
+			assert.ErrorContains(GinkgoT(), err, "response body data not valid")
+		})
+	})
+
+	Context("auth by resources response", func() {
+		var s *iamtest.Server
+		var i *IAM
+		path := "/api/v1/policy/auth_by_resources"
+		request := NewRequest("bk_paas", subject, NewAction("develop_app"), nil)
+		topoResourcesList := []Resources{
+			{
+				NewResourceNode("bk_paas", "project", "1", map[string]interface{}{}),
+				NewResourceNode("bk_paas", "app", "1", map[string]interface{}{}),
+			},
+			{
+				NewResourceNode("bk_paas", "project", "1", map[string]interface{}{}),
+				NewResourceNode("bk_paas", "app", "2", map[string]interface{}{}),
+			},
+		}
+
+		BeforeEach(func() {
+			s = iamtest.NewServer()
+			i = NewDirectIAM("bk_paas", "bk_paas", "{app_secret}", s.URL, WithServerSideAuth(true))
+		})
+
+		AfterEach(func() {
+			s.Close()
+		})
+
+		It("the keys of the resources", func() {
+			s.InjectFault(path, iamtest.Fault{StatusCode: http.StatusOK, RawBody: `{"code": 0, "message": "ok",
+				"data": {"1": true, "2": false}}`})
+			list, err := i.BatchIsAllowedList(request, resourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []ResourceAllowed{{ResourceID: "1", Allowed: true}, {ResourceID: "2"}}, list)
+
+			s.InjectFault(path, iamtest.Fault{StatusCode: http.StatusOK, RawBody: `{"code": 0, "message": "ok",
+				"data": {"project,1/app,1": false, "project,1/app,2": true}}`})
+			list, err = i.BatchIsAllowedList(request, topoResourcesList)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []ResourceAllowed{
+				{ResourceID: "project,1/app,1"},
+				{ResourceID: "project,1/app,2", Allowed: true},
+			}, list)
+		})
+
+		It("missing resource", func() {
+			s.InjectFault(path, iamtest.Fault{StatusCode: http.StatusOK, RawBody: `{"code": 0, "message": "ok",
+				"data": {"1": true}}`})
+			_, err := i.BatchIsAllowedList(request, resourcesList)
+			assert.ErrorContains(GinkgoT(), err, "resource `2` is missing")
+
+			// the keys of the multi-node resources are not the id of the last node
+			s.InjectFault(path, iamtest.Fault{StatusCode: http.StatusOK, RawBody: `{"code": 0, "message": "ok",
+				"data": {"1": false, "2": true}}`})
+			_, err = i.BatchIsAllowedList(request, topoResourcesList)
+			assert.ErrorContains(GinkgoT(), err, "resource `project,1/app,1` is missing")
+		})
+	})
+})
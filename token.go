@@ -0,0 +1,154 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/iam-go-sdk/logger"
+)
+
+const (
+	defaultTokenCacheTTL = 5 * time.Minute
+
+	// the token will be refreshed in background if older than ttl * tokenRefreshAheadRatio
+	tokenRefreshAheadRatio = 0.8
+	// the min interval of the forced refresh, avoid flooding iam backend by the requests with wrong password
+	minTokenForceRefreshInterval = 10 * time.Second
+	// the cached token will be used if the refresh fails, until older than ttl * tokenMaxStaleRatio
+	tokenMaxStaleRatio = 2
+)
+
+type tokenFetcher func(ctx context.Context) (string, error)
+
+// tokenCache caches the system token, refresh it in background before expired,
+// and keep using the last token if the refresh fails, until older than ttl * tokenMaxStaleRatio
+type tokenCache struct {
+	ttl   time.Duration
+	fetch tokenFetcher
+
+	mu         sync.Mutex
+	token      string
+	fetchedAt  time.Time
+	forcedAt   time.Time
+	refreshing bool
+	// fetching is the semaphore of the fetch, so the callers waiting for it can stop on ctx done
+	fetching chan struct{}
+}
+
+func newTokenCache(ttl time.Duration, fetch tokenFetcher) *tokenCache {
+	return &tokenCache{
+		ttl:      ttl,
+		fetch:    fetch,
+		fetching: make(chan struct{}, 1),
+	}
+}
+
+// get returns the cached token, fetch it if not cached or expired
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	if c.ttl <= 0 {
+		return c.fetch(ctx)
+	}
+
+	c.mu.Lock()
+	token, age := c.token, time.Since(c.fetchedAt)
+	if token != "" && age < c.ttl {
+		if age >= time.Duration(float64(c.ttl)*tokenRefreshAheadRatio) && !c.refreshing {
+			c.refreshing = true
+			go c.refreshInBackground()
+		}
+		c.mu.Unlock()
+		return token, nil
+	}
+	c.mu.Unlock()
+
+	return c.refresh(ctx, token, false)
+}
+
+// forceRefresh fetches the token again if it's still the stale one; after a forced fetch got the same token or failed,
+// e.g. the requests with wrong password, the next one is allowed after minTokenForceRefreshInterval;
+// returns the stale token if not fetched
+func (c *tokenCache) forceRefresh(ctx context.Context, stale string) (string, error) {
+	if c.ttl <= 0 {
+		return stale, nil
+	}
+	return c.refresh(ctx, stale, true)
+}
+
+func (c *tokenCache) refreshInBackground() {
+	defer func() {
+		c.mu.Lock()
+		c.refreshing = false
+		c.mu.Unlock()
+	}()
+
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	if _, err := c.refresh(context.Background(), token, false); err != nil {
+		logger.Warnf("refresh system token in background fail: %s", err)
+	}
+}
+
+// refresh fetches the token if it's still the stale one(not refreshed by others while waiting for the lock),
+// returns ctx.Err() if the ctx is done while waiting for the fetch of others
+func (c *tokenCache) refresh(ctx context.Context, stale string, force bool) (string, error) {
+	select {
+	case c.fetching <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-c.fetching }()
+
+	c.mu.Lock()
+	token, fetchedAt, forcedAt := c.token, c.fetchedAt, c.forcedAt
+	c.mu.Unlock()
+
+	if token != stale && token != "" {
+		return token, nil
+	}
+	if force {
+		if time.Since(forcedAt) < minTokenForceRefreshInterval {
+			return token, nil
+		}
+	} else if token != "" && time.Since(fetchedAt) < time.Duration(float64(c.ttl)*tokenRefreshAheadRatio) {
+		return token, nil
+	}
+
+	newToken, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// only limit the forced refresh which does not get a new token, so the refresh after a real rotation
+	// is not blocked by the requests with wrong password
+	if force && (err != nil || newToken == token) {
+		c.forcedAt = time.Now()
+	}
+	if err != nil {
+		if token != "" && !force && time.Since(fetchedAt) < time.Duration(tokenMaxStaleRatio)*c.ttl {
+			logger.Warnf("get system token fail, use the cached one: %s", err)
+			return token, nil
+		}
+		return "", err
+	}
+
+	if token != "" && newToken != token {
+		logger.Infof("the system token is rotated")
+	}
+	c.token = newToken
+	c.fetchedAt = time.Now()
+	return newToken, nil
+}
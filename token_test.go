@@ -0,0 +1,229 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making
+ * 蓝鲸智云 - 权限中心 Go SDK(iam-go-sdk) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ = Describe("token", func() {
+
+	Context("IsBasicAuthAllowed", func() {
+		var ts *httptest.Server
+		var token atomic.Value
+		var calls int32
+
+		BeforeEach(func() {
+			calls = 0
+			token.Store("abc")
+			ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprintf(w, `{"code": 0, "message": "ok", "data": {"token": "%s"}}`, token.Load())
+			}))
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("cached", func() {
+			i := NewDirectIAM("test", "app", "secret", ts.URL)
+
+			for n := 0; n < 3; n++ {
+				assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "abc"))
+			}
+			assert.Equal(GinkgoT(), int32(1), atomic.LoadInt32(&calls))
+		})
+
+		It("wrong username", func() {
+			i := NewDirectIAM("test", "app", "secret", ts.URL)
+
+			assert.Error(GinkgoT(), i.IsBasicAuthAllowed("admin", "abc"))
+			assert.Equal(GinkgoT(), int32(0), atomic.LoadInt32(&calls))
+		})
+
+		It("token rotated", func() {
+			i := NewDirectIAM("test", "app", "secret", ts.URL)
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "abc"))
+
+			token.Store("def")
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "def"))
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&calls))
+
+			// the old token is rejected, and the forced refresh is limited after got the same token
+			assert.Error(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "abc"))
+			assert.Error(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "xyz"))
+			assert.Equal(GinkgoT(), int32(3), atomic.LoadInt32(&calls))
+		})
+
+		It("token rotated again", func() {
+			i := NewDirectIAM("test", "app", "secret", ts.URL)
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "abc"))
+
+			token.Store("def")
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "def"))
+
+			// the refresh which got a new token does not limit the next one
+			token.Store("ghi")
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "ghi"))
+			assert.Equal(GinkgoT(), int32(3), atomic.LoadInt32(&calls))
+		})
+
+		It("cache disabled", func() {
+			i := NewDirectIAM("test", "app", "secret", ts.URL, WithTokenCacheTTL(0))
+
+			assert.NoError(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "abc"))
+			assert.Error(GinkgoT(), i.IsBasicAuthAllowed("bk_iam", "xyz"))
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&calls))
+		})
+	})
+
+	Context("tokenCache", func() {
+		var calls int32
+		var fetchErr error
+
+		fetch := func(ctx context.Context) (string, error) {
+			n := atomic.AddInt32(&calls, 1)
+			if fetchErr != nil {
+				return "", fetchErr
+			}
+			return fmt.Sprintf("token%d", n), nil
+		}
+
+		BeforeEach(func() {
+			calls = 0
+			fetchErr = nil
+		})
+
+		It("expired", func() {
+			c := newTokenCache(50*time.Millisecond, fetch)
+
+			token, err := c.get(context.Background())
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token1", token)
+
+			time.Sleep(60 * time.Millisecond)
+			token, err = c.get(context.Background())
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token2", token)
+		})
+
+		It("refresh in background", func() {
+			c := newTokenCache(100*time.Millisecond, fetch)
+
+			_, _ = c.get(context.Background())
+			time.Sleep(85 * time.Millisecond)
+
+			// still return the cached one, and refresh in background
+			token, err := c.get(context.Background())
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token1", token)
+
+			assert.Eventually(GinkgoT(), func() bool {
+				token, _ := c.get(context.Background())
+				return token == "token2"
+			}, time.Second, 5*time.Millisecond)
+		})
+
+		It("use the cached one if fetch fail", func() {
+			c := newTokenCache(50*time.Millisecond, fetch)
+			_, _ = c.get(context.Background())
+
+			fetchErr = errors.New("connection refused")
+			time.Sleep(60 * time.Millisecond)
+
+			token, err := c.get(context.Background())
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token1", token)
+		})
+
+		It("stop using the cached one after the max stale age", func() {
+			c := newTokenCache(50*time.Millisecond, fetch)
+			_, _ = c.get(context.Background())
+
+			fetchErr = errors.New("connection refused")
+			time.Sleep(110 * time.Millisecond)
+
+			_, err := c.get(context.Background())
+			assert.ErrorIs(GinkgoT(), err, fetchErr)
+		})
+
+		It("force refresh limited", func() {
+			c := newTokenCache(time.Minute, func(ctx context.Context) (string, error) {
+				atomic.AddInt32(&calls, 1)
+				return "token", nil
+			})
+			_, _ = c.get(context.Background())
+
+			// got the same token, the next one is limited
+			token, err := c.forceRefresh(context.Background(), "token")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token", token)
+			_, _ = c.forceRefresh(context.Background(), "token")
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&calls))
+		})
+
+		It("stop waiting for the fetch of others on ctx done", func() {
+			release := make(chan struct{})
+			defer close(release)
+			c := newTokenCache(time.Minute, func(ctx context.Context) (string, error) {
+				select {
+				case <-release:
+				case <-time.After(time.Second):
+				}
+				return "token", nil
+			})
+			go func() { _, _ = c.get(context.Background()) }()
+			assert.Eventually(GinkgoT(), func() bool { return len(c.fetching) == 1 }, time.Second, time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err := c.get(ctx)
+			assert.ErrorIs(GinkgoT(), err, context.DeadlineExceeded)
+			assert.Less(GinkgoT(), time.Since(start), 500*time.Millisecond)
+		})
+
+		It("fetch fail without cached one", func() {
+			fetchErr = errors.New("connection refused")
+			c := newTokenCache(50*time.Millisecond, fetch)
+
+			_, err := c.get(context.Background())
+			assert.ErrorIs(GinkgoT(), err, fetchErr)
+		})
+
+		It("force refresh", func() {
+			c := newTokenCache(time.Minute, fetch)
+			_, _ = c.get(context.Background())
+
+			token, err := c.forceRefresh(context.Background(), "token1")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token2", token)
+
+			// already refreshed by others
+			token, err = c.forceRefresh(context.Background(), "token1")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "token2", token)
+			assert.Equal(GinkgoT(), int32(2), atomic.LoadInt32(&calls))
+		})
+	})
+})
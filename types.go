@@ -16,6 +16,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	jsoniter "github.com/json-iterator/go"
@@ -170,11 +172,113 @@ func (mar *MultiActionRequest) Validate() error {
 }
 
 // ActionPolicy is the response struct
+//
+// Deprecated: the policy query by actions api is decoded into client.ActionPolicy now, this is no longer used by the sdk
 type ActionPolicy struct {
 	Action    Action              `json:"action"`
 	Condition expression.ExprCell `json:"condition"`
 }
 
+// ExplainResult is the result of IAM.ExplainIsAllowed, shows how the permission is evaluated
+type ExplainResult struct {
+	Allowed bool `json:"allowed"`
+
+	// Debug is the debug info returned by iam backend, e.g. the matched policies and the steps
+	Debug map[string]interface{} `json:"debug"`
+
+	Expr         expression.ExprCell `json:"expr"`
+	ExprString   string              `json:"expr_string"`
+	ExprRendered string              `json:"expr_rendered"`
+
+	// QueryTook is the time taken by the policy query, EvalTook is the time taken by the local eval
+	QueryTook time.Duration `json:"query_took"`
+	EvalTook  time.Duration `json:"eval_took"`
+}
+
+// PolicySubject is the subject of a policy, with the display name
+type PolicySubject struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Subject returns the subject without the name
+func (s PolicySubject) Subject() Subject {
+	return NewSubject(s.Type, s.ID)
+}
+
+// Policy is the policy granted to a subject
+type Policy struct {
+	ID      int64         `json:"id"`
+	Version string        `json:"version"`
+	Action  Action        `json:"action"`
+	Subject PolicySubject `json:"subject"`
+	// Expr is the condition of the policy, can be evaluated with the resources
+	Expr expression.ExprCell `json:"expression"`
+	// ExpiredAt is the unix timestamp(seconds) of the expiry
+	ExpiredAt int64 `json:"expired_at"`
+}
+
+// Expiry returns the expiry time of the policy
+func (p *Policy) Expiry() time.Time {
+	return time.Unix(p.ExpiredAt, 0)
+}
+
+// QueryPoliciesOptions is the options of query policies with action id
+type QueryPoliciesOptions struct {
+	// Page is the page number start from 1, default 1
+	Page int64
+	// PageSize is the number of policies per page, default 100
+	PageSize int64
+	// Timestamp is the unix timestamp(seconds), only the policies not expired at that time will be returned,
+	// default is now
+	Timestamp int64
+}
+
+// PolicyPage is one page of the policies of an action
+type PolicyPage struct {
+	// Count is the total count of the policies, not the count of this page
+	Count int64 `json:"count"`
+	// Timestamp is the timestamp used to filter the expired policies, pass it to the next page for consistent results
+	Timestamp int64    `json:"timestamp"`
+	Results   []Policy `json:"results"`
+}
+
+// AllowedSubject is the subject allowed to perform the action on the resources,
+// Policy is the policy grants the permission, the first one if there are multiple policies
+type AllowedSubject struct {
+	Subject PolicySubject `json:"subject"`
+	Policy  Policy        `json:"policy"`
+}
+
+// AuthorizedInstances is the instances of the resource types a subject is authorized for, an instance is authorized if:
+// Any, or the id in IDs[type], or the _bk_iam_path_ of it starts with one of Paths[type], or one of Remaining evals true
+type AuthorizedInstances struct {
+	// Any is true if all the instances are authorized
+	Any bool `json:"any"`
+	// IDs is the instance ids of each resource type, from `type.id eq/in`
+	IDs map[string][]string `json:"ids"`
+	// Paths is the _bk_iam_path_ prefixes of each resource type, from `type._bk_iam_path_ starts_with`,
+	// e.g. `/biz,1/set,` for `/biz,1/set,*/`
+	Paths map[string][]string `json:"paths"`
+	// Remaining is the expressions can not be reduced to ids or paths, should be evaluated by the caller
+	Remaining []expression.ExprCell `json:"remaining"`
+}
+
+// ResourceAllowed is the permission of the resources at the same index of the list passed to BatchIsAllowedList,
+// ResourceID is the key of the result of BatchIsAllowed
+type ResourceAllowed struct {
+	ResourceID string `json:"resource_id"`
+	Allowed    bool   `json:"allowed"`
+}
+
+// ResourceActionsAllowed is the permissions of the resources at the same index of the list passed to
+// BatchResourceMultiActionsAllowedList, ResourceID is the key of the result of BatchResourceMultiActionsAllowed
+type ResourceActionsAllowed struct {
+	ResourceID string          `json:"resource_id"`
+	Actions    map[string]bool `json:"actions"`
+}
+
 // ApplicationResourceNode  is the resourc node struct for application
 type ApplicationResourceNode struct {
 	Type string `json:"type" binding:"required"`
@@ -268,6 +372,548 @@ func NewApplication(system string, actions []ApplicationAction) Application {
 	}
 }
 
+// AuthorizationPolicy is the policy granted or revoked by the authorization apis
+type AuthorizationPolicy struct {
+	Action   Action `json:"action"`
+	PolicyID int64  `json:"policy_id"`
+}
+
+// ResourceCreatorAncestor is the ancestor of the created resource, e.g. the project of a task
+type ResourceCreatorAncestor struct {
+	System string `json:"system" binding:"required"`
+	Type   string `json:"type" binding:"required"`
+	ID     string `json:"id" binding:"required"`
+}
+
+// NewResourceCreatorAncestor will create the ancestor of the created resource
+func NewResourceCreatorAncestor(system, _type, id string) ResourceCreatorAncestor {
+	return ResourceCreatorAncestor{
+		System: system,
+		Type:   _type,
+		ID:     id,
+	}
+}
+
+// ResourceCreatorAncestors is the ancestors of the created resource, from the top to the nearest one
+type ResourceCreatorAncestors []ResourceCreatorAncestor
+
+// Validate will check if the ancestors are valid
+func (rca ResourceCreatorAncestors) Validate() error {
+	for i, node := range rca {
+		if node.System == "" || node.Type == "" || node.ID == "" {
+			return fmt.Errorf("the ancestors[%d] invalid: system, type and id should not be empty", i)
+		}
+	}
+
+	return nil
+}
+
+// ResourceCreatorActionRequest is the request to grant the creator the actions of the created resource
+type ResourceCreatorActionRequest struct {
+	System    string                   `json:"system" binding:"required"`
+	Type      string                   `json:"type" binding:"required"`
+	ID        string                   `json:"id" binding:"required"`
+	Name      string                   `json:"name" binding:"required"`
+	Creator   string                   `json:"creator" binding:"required"`
+	Ancestors ResourceCreatorAncestors `json:"ancestors,omitempty"`
+}
+
+// NewResourceCreatorActionRequest will create the request to grant the creator the actions of the created resource
+func NewResourceCreatorActionRequest(
+	system, _type, id, name, creator string,
+	ancestors []ResourceCreatorAncestor,
+) ResourceCreatorActionRequest {
+	return ResourceCreatorActionRequest{
+		System:    system,
+		Type:      _type,
+		ID:        id,
+		Name:      name,
+		Creator:   creator,
+		Ancestors: ancestors,
+	}
+}
+
+// Validate will check if the request is valid
+func (r *ResourceCreatorActionRequest) Validate() error {
+	if r.System == "" || r.Type == "" || r.ID == "" || r.Name == "" || r.Creator == "" {
+		return errors.New("the ResourceCreatorActionRequest invalid: system, type, id, name and creator should not be empty")
+	}
+
+	err := r.Ancestors.Validate()
+	if err != nil {
+		return fmt.Errorf("the ResourceCreatorActionRequest invalid: %w", err)
+	}
+
+	return nil
+}
+
+// ResourceCreatorInstance is one of the created resources in batch
+type ResourceCreatorInstance struct {
+	ID        string                   `json:"id" binding:"required"`
+	Name      string                   `json:"name" binding:"required"`
+	Ancestors ResourceCreatorAncestors `json:"ancestors,omitempty"`
+}
+
+// NewResourceCreatorInstance will create one of the created resources in batch
+func NewResourceCreatorInstance(id, name string, ancestors []ResourceCreatorAncestor) ResourceCreatorInstance {
+	return ResourceCreatorInstance{
+		ID:        id,
+		Name:      name,
+		Ancestors: ancestors,
+	}
+}
+
+// BatchResourceCreatorActionRequest is the request to grant the creator the actions of the created resources,
+// all the resources should be the same type
+type BatchResourceCreatorActionRequest struct {
+	System    string                    `json:"system" binding:"required"`
+	Type      string                    `json:"type" binding:"required"`
+	Creator   string                    `json:"creator" binding:"required"`
+	Instances []ResourceCreatorInstance `json:"instances" binding:"required"`
+}
+
+// NewBatchResourceCreatorActionRequest will create the request to grant the creator the actions of the resources
+func NewBatchResourceCreatorActionRequest(
+	system, _type, creator string,
+	instances []ResourceCreatorInstance,
+) BatchResourceCreatorActionRequest {
+	return BatchResourceCreatorActionRequest{
+		System:    system,
+		Type:      _type,
+		Creator:   creator,
+		Instances: instances,
+	}
+}
+
+// Validate will check if the request is valid
+func (r *BatchResourceCreatorActionRequest) Validate() error {
+	if r.System == "" || r.Type == "" || r.Creator == "" {
+		return errors.New("the BatchResourceCreatorActionRequest invalid: system, type and creator should not be empty")
+	}
+	if len(r.Instances) == 0 {
+		return errors.New("the BatchResourceCreatorActionRequest.instances invalid: should contain at least 1 instance")
+	}
+
+	for i, instance := range r.Instances {
+		if instance.ID == "" || instance.Name == "" {
+			return fmt.Errorf("the BatchResourceCreatorActionRequest.instances[%d] invalid: "+
+				"id and name should not be empty", i)
+		}
+
+		err := instance.Ancestors.Validate()
+		if err != nil {
+			return fmt.Errorf("the BatchResourceCreatorActionRequest.instances[%d] invalid: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// the operate of the grant or revoke permission apis
+const (
+	operateGrant  = "grant"
+	operateRevoke = "revoke"
+)
+
+// PermissionResourceNode is the resource node to grant or revoke, with the name of the instance
+type PermissionResourceNode struct {
+	ApplicationResourceNode
+	Name string `json:"name" binding:"required"`
+}
+
+// NewPermissionResourceNode will create the resource node to grant or revoke
+func NewPermissionResourceNode(_type, id, name string) PermissionResourceNode {
+	return PermissionResourceNode{
+		ApplicationResourceNode: ApplicationResourceNode{Type: _type, ID: id},
+		Name:                    name,
+	}
+}
+
+// Validate will check if the resource node is valid
+func (n *PermissionResourceNode) Validate() error {
+	if n.Type == "" || n.ID == "" || n.Name == "" {
+		return errors.New("type, id and name should not be empty")
+	}
+	return nil
+}
+
+// InstancePermissionResource is the resource instance to grant or revoke
+type InstancePermissionResource struct {
+	System string `json:"system" binding:"required"`
+	PermissionResourceNode
+}
+
+// NewInstancePermissionResource will create the resource instance to grant or revoke
+func NewInstancePermissionResource(system, _type, id, name string) InstancePermissionResource {
+	return InstancePermissionResource{
+		System:                 system,
+		PermissionResourceNode: NewPermissionResourceNode(_type, id, name),
+	}
+}
+
+// InstancePermissionRequest is the request to grant or revoke the permission of the action on the resource instances
+type InstancePermissionRequest struct {
+	Asynchronous bool                         `json:"asynchronous"`
+	Operate      string                       `json:"operate"`
+	System       string                       `json:"system" binding:"required"`
+	Subject      Subject                      `json:"subject" binding:"required"`
+	Action       Action                       `json:"action" binding:"required"`
+	Resources    []InstancePermissionResource `json:"resources" binding:"required"`
+	// ExpiredAt is the unix timestamp(seconds) of the expiry, 0 means using the default expiry of iam
+	ExpiredAt int64 `json:"expired_at,omitempty"`
+}
+
+// NewInstancePermissionRequest will create the request to grant or revoke the permission of the resource instances
+func NewInstancePermissionRequest(
+	system string,
+	subject Subject,
+	action Action,
+	resources []InstancePermissionResource,
+) InstancePermissionRequest {
+	return InstancePermissionRequest{
+		System:    system,
+		Subject:   subject,
+		Action:    action,
+		Resources: resources,
+	}
+}
+
+// WithExpiry will set the expiry of the granted permission
+func (r InstancePermissionRequest) WithExpiry(expiredAt time.Time) InstancePermissionRequest {
+	r.ExpiredAt = expiredAt.Unix()
+	return r
+}
+
+// Validate will check if the request is valid
+func (r *InstancePermissionRequest) Validate() error {
+	if r.System == "" || r.Subject.Type == "" || r.Subject.ID == "" || r.Action.ID == "" {
+		return errors.New("the InstancePermissionRequest invalid: system, subject and action should not be empty")
+	}
+	if len(r.Resources) == 0 {
+		return errors.New("the InstancePermissionRequest.resources invalid: should contain at least 1 resource")
+	}
+
+	for i, resource := range r.Resources {
+		if resource.System == "" {
+			return fmt.Errorf("the InstancePermissionRequest.resources[%d] invalid: system should not be empty", i)
+		}
+		err := resource.Validate()
+		if err != nil {
+			return fmt.Errorf("the InstancePermissionRequest.resources[%d] invalid: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// BatchInstancePermissionResource is the resource instances of one type to grant or revoke in batch,
+// each instance is a chain of resource nodes, e.g. [project, task]
+type BatchInstancePermissionResource struct {
+	System    string                     `json:"system" binding:"required"`
+	Type      string                     `json:"type" binding:"required"`
+	Instances [][]PermissionResourceNode `json:"instances" binding:"required"`
+}
+
+// NewBatchInstancePermissionResource will create the resource instances to grant or revoke in batch
+func NewBatchInstancePermissionResource(
+	system, _type string,
+	instances [][]PermissionResourceNode,
+) BatchInstancePermissionResource {
+	return BatchInstancePermissionResource{
+		System:    system,
+		Type:      _type,
+		Instances: instances,
+	}
+}
+
+// BatchInstancePermissionRequest is the request to grant or revoke the permissions of the actions
+// on the resource instances in batch
+type BatchInstancePermissionRequest struct {
+	Asynchronous bool                              `json:"asynchronous"`
+	Operate      string                            `json:"operate"`
+	System       string                            `json:"system" binding:"required"`
+	Subject      Subject                           `json:"subject" binding:"required"`
+	Actions      []Action                          `json:"actions" binding:"required"`
+	Resources    []BatchInstancePermissionResource `json:"resources" binding:"required"`
+	// ExpiredAt is the unix timestamp(seconds) of the expiry, 0 means using the default expiry of iam
+	ExpiredAt int64 `json:"expired_at,omitempty"`
+}
+
+// NewBatchInstancePermissionRequest will create the request to grant or revoke the permissions in batch
+func NewBatchInstancePermissionRequest(
+	system string,
+	subject Subject,
+	actions []Action,
+	resources []BatchInstancePermissionResource,
+) BatchInstancePermissionRequest {
+	return BatchInstancePermissionRequest{
+		System:    system,
+		Subject:   subject,
+		Actions:   actions,
+		Resources: resources,
+	}
+}
+
+// WithExpiry will set the expiry of the granted permissions
+func (r BatchInstancePermissionRequest) WithExpiry(expiredAt time.Time) BatchInstancePermissionRequest {
+	r.ExpiredAt = expiredAt.Unix()
+	return r
+}
+
+// Validate will check if the request is valid
+func (r *BatchInstancePermissionRequest) Validate() error {
+	if r.System == "" || r.Subject.Type == "" || r.Subject.ID == "" {
+		return errors.New("the BatchInstancePermissionRequest invalid: system and subject should not be empty")
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("the BatchInstancePermissionRequest.actions invalid: should contain at least 1 Action")
+	}
+	if len(r.Resources) == 0 {
+		return errors.New("the BatchInstancePermissionRequest.resources invalid: should contain at least 1 resource")
+	}
+
+	for i, resource := range r.Resources {
+		if resource.System == "" || resource.Type == "" {
+			return fmt.Errorf("the BatchInstancePermissionRequest.resources[%d] invalid: "+
+				"system and type should not be empty", i)
+		}
+		if len(resource.Instances) == 0 {
+			return fmt.Errorf("the BatchInstancePermissionRequest.resources[%d].instances invalid: "+
+				"should contain at least 1 instance", i)
+		}
+
+		for j, instance := range resource.Instances {
+			if len(instance) == 0 {
+				return fmt.Errorf("the BatchInstancePermissionRequest.resources[%d].instances[%d] invalid: "+
+					"should contain at least 1 PermissionResourceNode", i, j)
+			}
+			for k, node := range instance {
+				err := node.Validate()
+				if err != nil {
+					return fmt.Errorf("the BatchInstancePermissionRequest.resources[%d].instances[%d]"+
+						".PermissionResourceNode[%d] invalid: %w", i, j, k, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// PermissionPathAnyID is the id of the last node of a path, means all the instances of the type under the path
+const PermissionPathAnyID = "*"
+
+// PermissionPath is a topology path from the top ancestor, e.g. [biz, set, module],
+// grant the path means grant all the instances under the path
+type PermissionPath []PermissionResourceNode
+
+// NewPermissionPath will create the path from the ancestor resource nodes, top first;
+// the name of each node is the `name` attribute of the resource node, or the id if absent
+func NewPermissionPath(ancestors ...ResourceNode) PermissionPath {
+	path := make(PermissionPath, 0, len(ancestors))
+	for _, node := range ancestors {
+		name, ok := node.Attribute["name"].(string)
+		if !ok || name == "" {
+			name = node.ID
+		}
+		path = append(path, NewPermissionResourceNode(node.Type, node.ID, name))
+	}
+	return path
+}
+
+// String will render the path in the format of `_bk_iam_path_`, e.g. `/biz,1/set,2/module,3/`,
+// it's the value of the attribute `_bk_iam_path_` of the resources under the path
+func (p PermissionPath) String() string {
+	var b strings.Builder
+	b.WriteString("/")
+	for _, node := range p {
+		b.WriteString(node.Type)
+		b.WriteString(",")
+		b.WriteString(node.ID)
+		b.WriteString("/")
+	}
+	return b.String()
+}
+
+// Validate will check if the path is valid, only the last node can be `*`, and the name of `*` can be empty
+func (p PermissionPath) Validate() error {
+	if len(p) == 0 {
+		return errors.New("should contain at least 1 PermissionResourceNode")
+	}
+
+	for i, node := range p {
+		if node.ID == PermissionPathAnyID {
+			if i != len(p)-1 {
+				return fmt.Errorf("the PermissionResourceNode[%d] invalid: only the last node can be `*`", i)
+			}
+			if node.Type == "" {
+				return fmt.Errorf("the PermissionResourceNode[%d] invalid: type should not be empty", i)
+			}
+			continue
+		}
+
+		err := node.Validate()
+		if err != nil {
+			return fmt.Errorf("the PermissionResourceNode[%d] invalid: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// PathPermissionResource is the topology path to grant or revoke
+type PathPermissionResource struct {
+	System string         `json:"system" binding:"required"`
+	Type   string         `json:"type" binding:"required"`
+	Path   PermissionPath `json:"path" binding:"required"`
+}
+
+// NewPathPermissionResource will create the topology path of the resource type to grant or revoke
+func NewPathPermissionResource(system, _type string, path PermissionPath) PathPermissionResource {
+	return PathPermissionResource{
+		System: system,
+		Type:   _type,
+		Path:   path,
+	}
+}
+
+// PathPermissionRequest is the request to grant or revoke the permission of the action on the topology paths
+type PathPermissionRequest struct {
+	Asynchronous bool                     `json:"asynchronous"`
+	Operate      string                   `json:"operate"`
+	System       string                   `json:"system" binding:"required"`
+	Subject      Subject                  `json:"subject" binding:"required"`
+	Action       Action                   `json:"action" binding:"required"`
+	Resources    []PathPermissionResource `json:"resources" binding:"required"`
+	// ExpiredAt is the unix timestamp(seconds) of the expiry, 0 means using the default expiry of iam
+	ExpiredAt int64 `json:"expired_at,omitempty"`
+}
+
+// NewPathPermissionRequest will create the request to grant or revoke the permission of the topology paths
+func NewPathPermissionRequest(
+	system string,
+	subject Subject,
+	action Action,
+	resources []PathPermissionResource,
+) PathPermissionRequest {
+	return PathPermissionRequest{
+		System:    system,
+		Subject:   subject,
+		Action:    action,
+		Resources: resources,
+	}
+}
+
+// WithExpiry will set the expiry of the granted permission
+func (r PathPermissionRequest) WithExpiry(expiredAt time.Time) PathPermissionRequest {
+	r.ExpiredAt = expiredAt.Unix()
+	return r
+}
+
+// Validate will check if the request is valid
+func (r *PathPermissionRequest) Validate() error {
+	if r.System == "" || r.Subject.Type == "" || r.Subject.ID == "" || r.Action.ID == "" {
+		return errors.New("the PathPermissionRequest invalid: system, subject and action should not be empty")
+	}
+	if len(r.Resources) == 0 {
+		return errors.New("the PathPermissionRequest.resources invalid: should contain at least 1 resource")
+	}
+
+	for i, resource := range r.Resources {
+		if resource.System == "" || resource.Type == "" {
+			return fmt.Errorf("the PathPermissionRequest.resources[%d] invalid: system and type should not be empty", i)
+		}
+		err := resource.Path.Validate()
+		if err != nil {
+			return fmt.Errorf("the PathPermissionRequest.resources[%d].path invalid: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// BatchPathPermissionResource is the topology paths of one resource type to grant or revoke in batch
+type BatchPathPermissionResource struct {
+	System string           `json:"system" binding:"required"`
+	Type   string           `json:"type" binding:"required"`
+	Paths  []PermissionPath `json:"paths" binding:"required"`
+}
+
+// NewBatchPathPermissionResource will create the topology paths to grant or revoke in batch
+func NewBatchPathPermissionResource(system, _type string, paths []PermissionPath) BatchPathPermissionResource {
+	return BatchPathPermissionResource{
+		System: system,
+		Type:   _type,
+		Paths:  paths,
+	}
+}
+
+// BatchPathPermissionRequest is the request to grant or revoke the permissions of the actions
+// on the topology paths in batch
+type BatchPathPermissionRequest struct {
+	Asynchronous bool                          `json:"asynchronous"`
+	Operate      string                        `json:"operate"`
+	System       string                        `json:"system" binding:"required"`
+	Subject      Subject                       `json:"subject" binding:"required"`
+	Actions      []Action                      `json:"actions" binding:"required"`
+	Resources    []BatchPathPermissionResource `json:"resources" binding:"required"`
+	// ExpiredAt is the unix timestamp(seconds) of the expiry, 0 means using the default expiry of iam
+	ExpiredAt int64 `json:"expired_at,omitempty"`
+}
+
+// NewBatchPathPermissionRequest will create the request to grant or revoke the permissions of the paths in batch
+func NewBatchPathPermissionRequest(
+	system string,
+	subject Subject,
+	actions []Action,
+	resources []BatchPathPermissionResource,
+) BatchPathPermissionRequest {
+	return BatchPathPermissionRequest{
+		System:    system,
+		Subject:   subject,
+		Actions:   actions,
+		Resources: resources,
+	}
+}
+
+// WithExpiry will set the expiry of the granted permissions
+func (r BatchPathPermissionRequest) WithExpiry(expiredAt time.Time) BatchPathPermissionRequest {
+	r.ExpiredAt = expiredAt.Unix()
+	return r
+}
+
+// Validate will check if the request is valid
+func (r *BatchPathPermissionRequest) Validate() error {
+	if r.System == "" || r.Subject.Type == "" || r.Subject.ID == "" {
+		return errors.New("the BatchPathPermissionRequest invalid: system and subject should not be empty")
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("the BatchPathPermissionRequest.actions invalid: should contain at least 1 Action")
+	}
+	if len(r.Resources) == 0 {
+		return errors.New("the BatchPathPermissionRequest.resources invalid: should contain at least 1 resource")
+	}
+
+	for i, resource := range r.Resources {
+		if resource.System == "" || resource.Type == "" {
+			return fmt.Errorf("the BatchPathPermissionRequest.resources[%d] invalid: "+
+				"system and type should not be empty", i)
+		}
+		if len(resource.Paths) == 0 {
+			return fmt.Errorf("the BatchPathPermissionRequest.resources[%d].paths invalid: "+
+				"should contain at least 1 path", i)
+		}
+
+		for j, path := range resource.Paths {
+			err := path.Validate()
+			if err != nil {
+				return fmt.Errorf("the BatchPathPermissionRequest.resources[%d].paths[%d] invalid: %w", i, j, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // ApplicationResourceNodeWithName is the resourc node struct for application, which with the names of each field
 type ApplicationResourceNodeWithName struct {
 	Type     string `json:"type" binding:"required"`